@@ -14,7 +14,13 @@ import (
 type LocalCache struct {
 	stop      chan struct{}
 	mu        sync.RWMutex
-	companies map[uuid.UUID]model.Company
+	companies map[uuid.UUID]cacheEntry
+}
+
+// cacheEntry cached company, deleted entry is kept as tombstone with version of deleted company
+type cacheEntry struct {
+	company model.Company
+	deleted bool
 }
 
 var (
@@ -24,19 +30,23 @@ var (
 // NewLocalCache creates new company cache object
 func NewLocalCache() *LocalCache {
 	lc := &LocalCache{
-		companies: make(map[uuid.UUID]model.Company),
+		companies: make(map[uuid.UUID]cacheEntry),
 		stop:      make(chan struct{}),
 	}
 
 	return lc
 }
 
-// Update add or update entry to cache
-func (lc *LocalCache) Update(ID uuid.UUID, name string) {
+// Update add or update entry to cache, company is ignored unless its version is greater than version of cached
+// entry, so late events with stale snapshots don't overwrite newer entries
+func (lc *LocalCache) Update(company *model.Company) {
 	lc.mu.Lock()
 	defer lc.mu.Unlock()
 
-	lc.companies[ID] = model.Company{ID: ID, Name: name}
+	if cached, ok := lc.companies[company.ID]; ok && company.Version <= cached.company.Version {
+		return
+	}
+	lc.companies[company.ID] = cacheEntry{company: *company}
 }
 
 // Read read entry from cache
@@ -45,17 +55,21 @@ func (lc *LocalCache) Read(id uuid.UUID) (*model.Company, error) {
 	defer lc.mu.RUnlock()
 
 	cu, ok := lc.companies[id]
-	if !ok {
+	if !ok || cu.deleted {
 		return nil, errUserNotInCache
 	}
 
-	return &cu, nil
+	return &cu.company, nil
 }
 
-// Delete remove entry from cache
-func (lc *LocalCache) Delete(id uuid.UUID) {
+// Delete replaces entry by tombstone with version of deleted company, so it can be cached again
+// only by newer version, e.g. after restore
+func (lc *LocalCache) Delete(company *model.Company) {
 	lc.mu.Lock()
 	defer lc.mu.Unlock()
 
-	delete(lc.companies, id)
+	if cached, ok := lc.companies[company.ID]; ok && company.Version < cached.company.Version {
+		return
+	}
+	lc.companies[company.ID] = cacheEntry{company: model.Company{ID: company.ID, Version: company.Version}, deleted: true}
 }
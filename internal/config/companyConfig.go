@@ -0,0 +1,40 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// CompanyConfig config for company management
+type CompanyConfig struct {
+	AdminUserIDs  []string      `env:"ADMIN_USER_IDS" envSeparator:","`
+	Retention     time.Duration `env:"COMPANY_RETENTION" envDefault:"720h"`
+	PurgeInterval time.Duration `env:"COMPANY_PURGE_INTERVAL" envDefault:"1h"`
+	// RequireIfMatch rejects modifications without If-Match header
+	RequireIfMatch bool `env:"COMPANY_REQUIRE_IF_MATCH" envDefault:"false"`
+	// DuplicateThreshold minimal similarity (0-1) of normalized names of companies considered duplicates
+	DuplicateThreshold float64 `env:"COMPANY_DUPLICATE_THRESHOLD" envDefault:"0.6"`
+	// ChangesRetention age of changes feed entries after which they are purged
+	ChangesRetention time.Duration `env:"COMPANY_CHANGES_RETENTION" envDefault:"720h"`
+	// LogoSizes dimensions in pixels of bounding squares of logo variants generated on upload
+	LogoSizes []int `env:"COMPANY_LOGO_SIZES" envSeparator:"," envDefault:"32,64,256"`
+	// LogoVersions number of latest logo versions kept per company, older ones are removed with their images
+	LogoVersions int `env:"COMPANY_LOGO_VERSIONS" envDefault:"10"`
+	// LogoMaxBytes maximum size of uploaded logo file in bytes
+	LogoMaxBytes int64 `env:"COMPANY_LOGO_MAX_BYTES" envDefault:"5242880"`
+	// LogoMaxDimension maximum width and height of uploaded raster logo in pixels
+	LogoMaxDimension int `env:"COMPANY_LOGO_MAX_DIMENSION" envDefault:"4096"`
+	// LogoMaxPixels maximum number of pixels of uploaded raster logo, protects against decompression bombs
+	LogoMaxPixels int `env:"COMPANY_LOGO_MAX_PIXELS" envDefault:"16777216"`
+}
+
+// NewCompanyConfig creates new CompanyConfig object
+func NewCompanyConfig() (*CompanyConfig, error) {
+	cfg := new(CompanyConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/caarlos0/env/v6"
+)
+
+// StorageConfig config of blob storage for uploaded files
+type StorageConfig struct {
+	// Driver one of filesystem, memory or s3
+	Driver string `env:"STORAGE_DRIVER" envDefault:"filesystem"`
+	// Root directory of filesystem driver, relative to working directory
+	Root        string `env:"STORAGE_ROOT" envDefault:"data"`
+	S3Endpoint  string `env:"STORAGE_S3_ENDPOINT"`
+	S3Bucket    string `env:"STORAGE_S3_BUCKET"`
+	S3Region    string `env:"STORAGE_S3_REGION" envDefault:"us-east-1"`
+	S3AccessKey string `env:"STORAGE_S3_ACCESS_KEY"`
+	S3SecretKey string `env:"STORAGE_S3_SECRET_KEY"`
+}
+
+// NewStorageConfig creates new StorageConfig object
+func NewStorageConfig() (*StorageConfig, error) {
+	cfg := new(StorageConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -3,17 +3,65 @@ package consumer
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v9"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+)
+
+const (
+	// groupBlock time consumer group member waits for new messages, pending messages are retried in between
+	groupBlock = 5 * time.Second
+	// retryIdle time after which message left unacknowledged by failed callback or stopped consumer is retried
+	retryIdle = time.Minute
+	// retryBatch maximum number of pending messages retried at once
+	retryBatch = 100
+	// maxDeliveries number of deliveries after which failing message is dropped
+	maxDeliveries = 10
+	minBackoff    = 100 * time.Millisecond
+	maxBackoff    = 10 * time.Second
 )
 
 // Company consuming company messages
 type Company interface {
-	Consume(ctx context.Context, callbackFunc func(id uuid.UUID, action, name string))
+	// Consume passes messages to callbackFunc until ctx is done, message which callback failed to handle
+	// is retried if consumer supports it
+	Consume(ctx context.Context, callbackFunc func(action string, company *model.Company) error)
+}
+
+// backoff delay after failed read from redis doubling with each consecutive failure
+type backoff struct {
+	delay time.Duration
+}
+
+// wait sleeps for the next delay, returns false if ctx is done meanwhile
+func (b *backoff) wait(ctx context.Context) bool {
+	b.delay *= 2
+	if b.delay < minBackoff {
+		b.delay = minBackoff
+	}
+	if b.delay > maxBackoff {
+		b.delay = maxBackoff
+	}
+	timer := time.NewTimer(b.delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (b *backoff) reset() {
+	b.delay = 0
 }
 
 type redisCompany struct {
@@ -28,48 +76,198 @@ func NewRedisCompanyConsumer(redisClient *redis.Client, startID string) Company
 		lastID: startID}
 }
 
-// Consume get message from redis stream
-func (c *redisCompany) Consume(ctx context.Context, callbackFunc func(id uuid.UUID, action, name string)) {
-	for {
+// Consume get message from redis stream until ctx is done, reading is retried with backoff after failure
+func (c *redisCompany) Consume(ctx context.Context, callbackFunc func(action string, company *model.Company) error) {
+	var failures backoff
+	for ctx.Err() == nil {
 		args := &redis.XReadArgs{
 			Streams: []string{"company", c.lastID},
 		}
 		r, err := c.redis.XRead(ctx, args).Result()
 		if err != nil {
 			log.Error(err)
+			if !failures.wait(ctx) {
+				return
+			}
+			continue
 		}
+		failures.reset()
 
 		for _, message := range r[0].Messages {
-			id, action, name, decodeErr := decode(message)
+			c.lastID = message.ID
+			action, company, decodeErr := decode(message)
 			if decodeErr != nil {
-				log.Error(err)
+				log.Error(decodeErr)
+				continue
 			}
 
-			fmt.Printf("consumed message from redis: {%v, %s}\n", id, name)
-			callbackFunc(id, action, name)
-			c.lastID = message.ID
+			fmt.Printf("consumed message from redis: {%v, %s}\n", company.ID, company.Name)
+			if err = callbackFunc(action, company); err != nil {
+				log.Error(err)
+			}
 		}
 	}
 }
 
-func decode(message redis.XMessage) (id uuid.UUID, action, name string, err error) {
+func decode(message redis.XMessage) (action string, company *model.Company, err error) {
 	action, ok := message.Values["event"].(string)
 	if !ok {
-		return id, action, name, errors.New("cannot convert action to string")
+		return action, nil, errors.New("cannot convert action to string")
 	}
 	idStr, ok := message.Values["id"].(string)
 	if !ok {
-		return id, action, name, errors.New("cannot convert id to string")
+		return action, nil, errors.New("cannot convert id to string")
 	}
-	name, ok = message.Values["name"].(string)
+	name, ok := message.Values["name"].(string)
 	if !ok {
-		return id, action, name, errors.New("cannot convert name to string")
+		return action, nil, errors.New("cannot convert name to string")
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return action, nil, err
+	}
+
+	company = &model.Company{ID: id, Name: name}
+	if payload, ok := message.Values["company"].(string); ok {
+		err = json.Unmarshal([]byte(payload), company)
+		if err != nil {
+			return action, nil, err
+		}
+	}
+
+	return action, company, nil
+}
+
+type redisCompanyGroup struct {
+	redis *redis.Client
+	group string
+	name  string
+}
+
+// NewRedisCompanyGroupConsumer creates redis company consumer reading as a member of consumer group,
+// so that each message is handled by only one of the service instances
+func NewRedisCompanyGroupConsumer(redisClient *redis.Client, group, name string) Company {
+	return &redisCompanyGroup{
+		redis: redisClient,
+		group: group,
+		name:  name}
+}
+
+// Consume get message from redis stream and acknowledge it after successful callback, messages left pending
+// by previous run of the consumer are handled first. Messages which stay unacknowledged because callback failed
+// or their consumer stopped are claimed and retried until they are delivered maxDeliveries times
+func (c *redisCompanyGroup) Consume(ctx context.Context, callbackFunc func(action string, company *model.Company) error) {
+	err := c.redis.XGroupCreateMkStream(ctx, "company", c.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Error(err)
+	}
+
+	var failures backoff
+	lastID := "0"
+	lastRetry := time.Now()
+	for ctx.Err() == nil {
+		if lastID == ">" && time.Since(lastRetry) >= retryIdle {
+			c.retryPending(ctx, callbackFunc)
+			lastRetry = time.Now()
+		}
+		args := &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{"company", lastID},
+			Block:    groupBlock,
+		}
+		r, err := c.redis.XReadGroup(ctx, args).Result()
+		if errors.Is(err, redis.Nil) {
+			failures.reset()
+			continue
+		}
+		if err != nil {
+			log.Error(err)
+			if !failures.wait(ctx) {
+				return
+			}
+			continue
+		}
+		failures.reset()
+
+		messages := r[0].Messages
+		if lastID != ">" {
+			// messages pending since previous run are read page by page, failed ones are left for retry
+			if len(messages) == 0 {
+				lastID = ">"
+				continue
+			}
+			lastID = messages[len(messages)-1].ID
+		}
+		for _, message := range messages {
+			c.handle(ctx, message, callbackFunc)
+		}
 	}
+}
 
-	id, err = uuid.Parse(idStr)
+// retryPending claims messages unacknowledged for retryIdle by any member of the group and handles them again,
+// messages delivered maxDeliveries times are dropped
+func (c *redisCompanyGroup) retryPending(ctx context.Context, callbackFunc func(action string, company *model.Company) error) {
+	pending, err := c.redis.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: "company",
+		Group:  c.group,
+		Idle:   retryIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  retryBatch,
+	}).Result()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	var ids []string
+	for _, entry := range pending {
+		if entry.RetryCount >= maxDeliveries {
+			log.Errorf("dropping company message %s delivered %d times", entry.ID, entry.RetryCount)
+			c.ack(ctx, entry.ID)
+			continue
+		}
+		ids = append(ids, entry.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+	messages, err := c.redis.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   "company",
+		Group:    c.group,
+		Consumer: c.name,
+		MinIdle:  retryIdle,
+		Messages: ids,
+	}).Result()
 	if err != nil {
-		return id, action, name, err
+		log.Error(err)
+		return
+	}
+	for _, message := range messages {
+		c.handle(ctx, message, callbackFunc)
 	}
+}
 
-	return id, action, name, nil
+// handle passes message to callback and acknowledges it if callback succeeds, otherwise message stays pending.
+// Malformed messages are acknowledged because they can never be handled
+func (c *redisCompanyGroup) handle(ctx context.Context, message redis.XMessage,
+	callbackFunc func(action string, company *model.Company) error) {
+	action, company, err := decode(message)
+	if err != nil {
+		log.Error(err)
+		c.ack(ctx, message.ID)
+		return
+	}
+	if err = callbackFunc(action, company); err != nil {
+		log.Errorf("cannot handle company message %s: %v", message.ID, err)
+		return
+	}
+	c.ack(ctx, message.ID)
+}
+
+func (c *redisCompanyGroup) ack(ctx context.Context, id string) {
+	if err := c.redis.XAck(ctx, "company", c.group, id).Err(); err != nil {
+		log.Error(err)
+	}
 }
@@ -6,4 +6,8 @@ const (
 	UPDATE = "UPDATE"
 	// DELETE redis action for delete from cache
 	DELETE = "DELETE"
+	// HIERARCHY redis action for change of company parent, entry in cache is updated
+	HIERARCHY = "HIERARCHY"
+	// CACHE redis action for add entry read from db in cache, company itself is unchanged
+	CACHE = "CACHE"
 )
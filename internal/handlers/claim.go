@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/middleware"
+)
+
+// currentUserID returns id of user authenticated by access token
+func currentUserID(ctx echo.Context) (uuid.UUID, error) {
+	claim, err := middleware.GetClaim(ctx)
+	if err != nil {
+		return uuid.Nil, echo.ErrUnauthorized
+	}
+	id, err := uuid.Parse(claim.UserID)
+	if err != nil {
+		return uuid.Nil, echo.ErrUnauthorized
+	}
+	return id, nil
+}
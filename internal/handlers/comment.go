@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/service"
+)
+
+// Comment handler company comments struct
+type Comment struct {
+	commentService *service.Comment
+}
+
+// NewComment creates new company comments handler
+func NewComment(commentService *service.Comment) *Comment {
+	return &Comment{commentService: commentService}
+}
+
+// GetAll godoc
+// @Summary Retrieves page of top level company comments, oldest first
+// @Tags    comments
+// @Produce json
+// @Param   id     path     string true  "company uuid"
+// @Param   limit  query    int    false "page size (1-100, default 20)"
+// @Param   cursor query    string false "next page cursor from previous response"
+// @Success 200    {object} model.CommentPage
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/comments [get]
+func (c *Comment) GetAll(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	cursor, limit, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.commentService.GetAll(ctx.Request().Context(), userID, companyID, cursor, limit)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// GetReplies godoc
+// @Summary Retrieves page of replies to company comment, oldest first
+// @Tags    comments
+// @Produce json
+// @Param   id        path     string true  "company uuid"
+// @Param   commentId path     string true  "comment uuid"
+// @Param   limit     query    int    false "page size (1-100, default 20)"
+// @Param   cursor    query    string false "next page cursor from previous response"
+// @Success 200       {object} model.CommentPage
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/comments/{commentId}/replies [get]
+func (c *Comment) GetReplies(ctx echo.Context) error {
+	companyID, id, err := commentIDs(ctx)
+	if err != nil {
+		return err
+	}
+	cursor, limit, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.commentService.GetReplies(ctx.Request().Context(), userID, companyID, id, cursor, limit)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// Create godoc
+// @Summary comment company or reply to comment, @username mentions are resolved to user ids
+// @Tags    comments
+// @Accept  json
+// @Produce json
+// @Param   id    path     string               true "company uuid"
+// @Param   input body     createCommentRequest true "comment, parentId is set for replies"
+// @Success 200   {object} model.Comment
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router  /company/{id}/comments [post]
+func (c *Comment) Create(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(createCommentRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	comment := &model.Comment{
+		CompanyID: companyID,
+		ParentID:  request.ParentID,
+		AuthorID:  userID,
+		Body:      request.Body,
+	}
+	err = c.commentService.Create(ctx.Request().Context(), comment)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, comment)
+}
+
+// Update godoc
+// @Summary edit own company comment
+// @Tags    comments
+// @Accept  json
+// @Produce json
+// @Param   id        path     string               true "company uuid"
+// @Param   commentId path     string               true "comment uuid"
+// @Param   input     body     updateCommentRequest true "new comment body"
+// @Success 200       {object} model.Comment
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/comments/{commentId} [put]
+func (c *Comment) Update(ctx echo.Context) error {
+	companyID, id, err := commentIDs(ctx)
+	if err != nil {
+		return err
+	}
+	request := new(updateCommentRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	comment := &model.Comment{ID: id, CompanyID: companyID, Body: request.Body}
+	err = c.commentService.Update(ctx.Request().Context(), userID, comment)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, comment)
+}
+
+// Delete godoc
+// @Summary delete own company comment, replies are kept
+// @Tags    comments
+// @Param   id        path string true "company uuid"
+// @Param   commentId path string true "comment uuid"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/comments/{commentId} [delete]
+func (c *Comment) Delete(ctx echo.Context) error {
+	companyID, id, err := commentIDs(ctx)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.commentService.Delete(ctx.Request().Context(), userID, companyID, id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Comment deleted")
+}
+
+// commentIDs parses company and comment ids from path
+func commentIDs(ctx echo.Context) (companyID, id uuid.UUID, err error) {
+	companyID, err = uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	id, err = uuid.Parse(ctx.Param("commentId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	return companyID, id, nil
+}
+
+// bindPage binds and validates page query parameters
+func bindPage(ctx echo.Context) (*model.Cursor, int, error) {
+	request := new(pageRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return nil, 0, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return nil, 0, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	cursor, limit, err := request.page()
+	if err != nil {
+		return nil, 0, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return cursor, limit, nil
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"github.com/google/uuid"
+)
+
+type createCommentRequest struct {
+	Body     string     `json:"body" validate:"required,max=10000"`
+	ParentID *uuid.UUID `json:"parentId"`
+}
+
+type updateCommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"path"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 
+	"entetry/gotest/internal/config"
 	"entetry/gotest/internal/model"
 	"entetry/gotest/internal/service"
 )
@@ -14,32 +17,101 @@ import (
 // Company handler company struct
 type Company struct {
 	companyService *service.Company
+	requireIfMatch bool
+	logoMaxBytes   int64
 }
 
 // NewCompany creates new company handler
-func NewCompany(companyService *service.Company) *Company {
-	return &Company{companyService: companyService}
+func NewCompany(companyService *service.Company, cfg *config.CompanyConfig) *Company {
+	return &Company{companyService: companyService, requireIfMatch: cfg.RequireIfMatch, logoMaxBytes: cfg.LogoMaxBytes}
 }
 
 // GetAll godoc
-// @Summary Retrieves all companies
+// @Summary Retrieves page of companies
 // @Produce json
-// @Success 200 {array} model.Company
+// @Param   limit          query    int    false "page size (1-100, default 20)"
+// @Param   cursor         query    string false "next page cursor from previous response"
+// @Param   name           query    string false "company name filter"
+// @Param   match          query    string false "name filter mode" Enums(prefix, contains)
+// @Param   sort           query    string false "sort field" Enums(name, id)
+// @Param   order          query    string false "sort order" Enums(asc, desc)
+// @Param   updated_since  query    string false "RFC 3339 time, only companies changed at or after it"
+// @Param   created_before query    string false "RFC 3339 time, only companies created before it"
+// @Success 200            {object} model.CompanyPage
 // @Failure 400
+// @Failure 500
 // @Router  /company [get]
 func (c *Company) GetAll(ctx echo.Context) error {
-	companies, err := c.companyService.GetAll(ctx.Request().Context())
+	request := new(getCompaniesRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	filter, err := request.toFilter()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	page, err := c.companyService.GetAll(ctx.Request().Context(), filter)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// Search godoc
+// @Summary Searches companies by partial or misspelled name
+// @Produce json
+// @Param   q     query string true  "search query"
+// @Param   limit query int    false "max results (1-100, default 20)"
+// @Success 200   {array} model.CompanySearchResult
+// @Failure 400
+// @Failure 500
+// @Router  /company/search [get]
+func (c *Company) Search(ctx echo.Context) error {
+	request := new(searchCompaniesRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
 	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultPageLimit
+	}
+
+	results, err := c.companyService.Search(ctx.Request().Context(), request.Query, request.Limit)
+	if err != nil {
+		log.Error(err)
 		return echo.NewHTTPError(http.StatusInternalServerError)
 	}
-	return ctx.JSON(http.StatusOK, companies)
+	return ctx.JSON(http.StatusOK, results)
 }
 
 // GetByID godoc
 // @Summary Retrieves company based on given ID
 // @Produce json
-// @Success 200 {object} model.Company
+// @Param   id            path     string true  "company uuid"
+// @Param   If-None-Match header   string false "entity tag of cached company"
+// @Success 200           {object} model.Company
+// @Success 301
+// @Success 304
 // @Failure 400
+// @Failure 404
 // @Router  /company/{id} [get]
 func (c *Company) GetByID(ctx echo.Context) error {
 	id, err := uuid.Parse(ctx.Param("id"))
@@ -48,19 +120,74 @@ func (c *Company) GetByID(ctx echo.Context) error {
 	}
 	company, err := c.companyService.GetByID(ctx.Request().Context(), id)
 
+	var moved *model.MovedError
+	if errors.As(err, &moved) {
+		return ctx.Redirect(http.StatusMovedPermanently, path.Join(path.Dir(ctx.Request().URL.Path), moved.ID.String()))
+	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest)
+		return companyError(err)
 	}
 
+	ctx.Response().Header().Set(headerETag, etag(company.Version))
+	if ifNoneMatch(ctx, company.Version) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
 	return ctx.JSON(http.StatusOK, company)
 }
 
+// GetDeleted godoc
+// @Summary Retrieves page of deleted companies, available for administrators only
+// @Produce json
+// @Param   limit          query    int    false "page size (1-100, default 20)"
+// @Param   cursor         query    string false "next page cursor from previous response"
+// @Param   name           query    string false "company name filter"
+// @Param   match          query    string false "name filter mode" Enums(prefix, contains)
+// @Param   sort           query    string false "sort field" Enums(name, id)
+// @Param   order          query    string false "sort order" Enums(asc, desc)
+// @Param   updated_since  query    string false "RFC 3339 time, only companies changed at or after it"
+// @Param   created_before query    string false "RFC 3339 time, only companies created before it"
+// @Success 200            {object} model.CompanyPage
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router  /company/trash [get]
+func (c *Company) GetDeleted(ctx echo.Context) error {
+	request := new(getCompaniesRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	filter, err := request.toFilter()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	page, err := c.companyService.GetDeleted(ctx.Request().Context(), filter)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
 // Create godoc
 // @Summary create company
 // @Produce json
-// @Param   input body addCompanyRequest true "name"
+// @Param   input body  addCompanyRequest true  "company profile"
+// @Param   force query bool              false "create company even if companies with similar names exist"
 // @Success 200
 // @Failure 400
+// @Failure 403
+// @Failure 409
+// @Failure 422
 // @Failure 500
 // @Router  /company [post]
 func (c *Company) Create(ctx echo.Context) error {
@@ -76,10 +203,18 @@ func (c *Company) Create(ctx echo.Context) error {
 		log.Error(err)
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	company := &model.Company{Name: request.Name}
-	id, err := c.companyService.Create(ctx.Request().Context(), company)
+	force, err := boolQueryParam(ctx, "force")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError)
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	company := request.toModel()
+	id, err := c.companyService.Create(ctx.Request().Context(), userID, company, force)
+	if err != nil {
+		return companyError(err)
 	}
 	return ctx.JSON(http.StatusOK, id)
 }
@@ -87,9 +222,16 @@ func (c *Company) Create(ctx echo.Context) error {
 // Update godoc
 // @Summary update company
 // @Produce json
-// @Param   input body updateCompanyRequest true "uuid" "name"
+// @Param   input    body   updateCompanyRequest true  "company uuid and profile"
+// @Param   If-Match header string               false "entity tag of modified company"
+// @Param   force    query  bool                 false "save company even if companies with similar names exist"
 // @Success 200
 // @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 428
 // @Failure 500
 // @Router  /company [put]
 func (c *Company) Update(ctx echo.Context) error {
@@ -106,76 +248,165 @@ func (c *Company) Update(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	company := &model.Company{ID: request.UUID, Name: request.Name}
-	err = c.companyService.Update(ctx.Request().Context(), company)
+	version, err := ifMatchVersion(ctx, c.requireIfMatch)
+	if err != nil {
+		return err
+	}
+	force, err := boolQueryParam(ctx, "force")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	company := request.toModel()
+	company.ID = request.UUID
+	company.Version = version
+	err = c.companyService.Update(ctx.Request().Context(), userID, company, force)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError)
+		return companyError(err)
 	}
 
+	ctx.Response().Header().Set(headerETag, etag(company.Version))
 	return ctx.JSON(http.StatusOK, "Company updated")
 }
 
 // Delete godoc
-// @Summary delete company based on given ID
+// @Summary move company based on given ID to trash
 // @Produce json
+// @Param   id       path   string true  "company uuid"
+// @Param   If-Match header string false "entity tag of deleted company"
 // @Success 200
 // @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 412
+// @Failure 428
 // @Router  /company/{id} [delete]
 func (c *Company) Delete(ctx echo.Context) error {
 	id, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest)
 	}
-	err = c.companyService.Delete(ctx.Request().Context(), id)
+	version, err := ifMatchVersion(ctx, c.requireIfMatch)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.companyService.Delete(ctx.Request().Context(), userID, id, version)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest)
+		return companyError(err)
 	}
 	return ctx.JSON(http.StatusOK, "Company deleted")
 }
 
-// GetLogoByCompanyID godoc
-// @Summary Retrieves company logo based on given company ID
+// Restore godoc
+// @Summary restore deleted company based on given ID
 // @Produce json
+// @Param   id path string true "company uuid"
 // @Success 200
 // @Failure 400
-// @Failure 500
-// @Router  /company/logo/{id} [get]
-func (c *Company) GetLogoByCompanyID(ctx echo.Context) error {
+// @Failure 403
+// @Failure 404
+// @Router  /company/{id}/restore [post]
+func (c *Company) Restore(ctx echo.Context) error {
 	id, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest)
 	}
-
-	logo, err := c.companyService.GetLogo(ctx.Request().Context(), id)
+	userID, err := currentUserID(ctx)
 	if err != nil {
-		log.Error(err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	if logo == "" {
-		return echo.NewHTTPError(http.StatusNotFound)
+	err = c.companyService.Restore(ctx.Request().Context(), userID, id)
+
+	if err != nil {
+		return companyError(err)
 	}
-	return ctx.File(logo)
+	return ctx.JSON(http.StatusOK, "Company restored")
 }
 
-// AddLogo godoc
-// @Summary add new company logo
-// @Produce mpfd
-// @Success 200
+// GetHistory godoc
+// @Summary Retrieves page of company changes, newest first
+// @Produce json
+// @Param   id     path     string true  "company uuid"
+// @Param   limit  query    int    false "page size (1-100, default 20)"
+// @Param   cursor query    string false "next page cursor from previous response"
+// @Success 200    {object} model.CompanyHistoryPage
+// @Failure 400
 // @Failure 500
-// @Router  /company/logo [post]
-func (c *Company) AddLogo(ctx echo.Context) error {
-	companyID := ctx.FormValue("companyID")
-	file, err := ctx.FormFile("image")
+// @Router  /company/{id}/history [get]
+func (c *Company) GetHistory(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(pageRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
 	if err != nil {
 		log.Error(err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	cursor, limit, err := request.page()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	err = c.companyService.AddLogo(ctx.Request().Context(), companyID, file)
+
+	page, err := c.companyService.GetHistory(ctx.Request().Context(), id, cursor, limit)
 	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// duplicateResponse body of 409 response to creation of possible duplicate
+type duplicateResponse struct {
+	Message    string
+	Candidates []*model.CompanyMatch
+}
+
+// companyError converts company service error into http error
+func companyError(err error) error {
+	var httpErr *echo.HTTPError
+	var duplicate *model.DuplicateError
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr
+	case errors.Is(err, model.ErrVersionConflict):
+		return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, model.ErrForbidden):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, model.ErrLastOwner), errors.Is(err, model.ErrLogoExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, model.ErrChangesExpired):
+		return echo.NewHTTPError(http.StatusGone, err.Error())
+	case errors.Is(err, model.ErrUnsupportedMediaType):
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, model.ErrFileTooLarge), errors.Is(err, model.ErrImageTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, model.ErrParentNotFound), errors.Is(err, model.ErrHierarchyCycle),
+		errors.Is(err, model.ErrParentCommentNotFound):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.As(err, &duplicate):
+		return echo.NewHTTPError(http.StatusConflict, &duplicateResponse{
+			Message:    duplicate.Error(),
+			Candidates: duplicate.Candidates,
+		})
+	default:
 		log.Error(err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError)
 	}
-	return ctx.JSON(http.StatusOK, "Logo has been added")
 }
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/config"
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/service"
+)
+
+const batchModeBestEffort = "bestEffort"
+
+type batchOperationResult struct {
+	ID      uuid.UUID
+	Version int64
+	Status  int
+	Error   interface{} `json:",omitempty"`
+}
+
+type batchResponse struct {
+	Committed bool
+	Results   []*batchOperationResult
+}
+
+// CompanyBatch handler company batch struct
+type CompanyBatch struct {
+	batchService   *service.CompanyBatch
+	requireIfMatch bool
+}
+
+// NewCompanyBatch creates new company batch handler
+func NewCompanyBatch(batchService *service.CompanyBatch, cfg *config.CompanyConfig) *CompanyBatch {
+	return &CompanyBatch{batchService: batchService, requireIfMatch: cfg.RequireIfMatch}
+}
+
+// Execute godoc
+// @Summary create, update and delete companies in one transaction
+// @Description In atomic mode (default) the first failed operation rolls back the whole batch, the batch is
+// @Description answered with 422 and operations which haven't failed have status 424. In bestEffort mode
+// @Description only failed operations are rolled back. Cache events are published after commit
+// @Accept  json
+// @Produce json
+// @Param   input body     batchRequest true "up to 500 operations, version 0 skips concurrent modification check"
+// @Success 200   {object} batchResponse
+// @Failure 400
+// @Failure 422   {object} batchResponse
+// @Failure 428
+// @Failure 500
+// @Router  /company/batch [post]
+func (c *CompanyBatch) Execute(ctx echo.Context) error {
+	request := new(batchRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	operations := make([]*model.CompanyOperation, len(request.Operations))
+	for i, operation := range request.Operations {
+		if c.requireIfMatch && operation.Op != model.OperationCreate && operation.Version == 0 {
+			return echo.NewHTTPError(http.StatusPreconditionRequired, "version of updated and deleted companies is required")
+		}
+		operations[i] = operation.toModel()
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	results, committed, err := c.batchService.Execute(ctx.Request().Context(), userID, operations,
+		request.Mode != batchModeBestEffort)
+	if err != nil {
+		return companyError(err)
+	}
+
+	response := &batchResponse{Committed: committed, Results: make([]*batchOperationResult, len(results))}
+	for i, result := range results {
+		response.Results[i] = newBatchOperationResult(result, committed)
+	}
+	if !committed {
+		return ctx.JSON(http.StatusUnprocessableEntity, response)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func newBatchOperationResult(result *model.CompanyOperationResult, committed bool) *batchOperationResult {
+	switch {
+	case result.Err != nil:
+		var httpErr *echo.HTTPError
+		if !errors.As(companyError(result.Err), &httpErr) {
+			return &batchOperationResult{Status: http.StatusInternalServerError}
+		}
+		return &batchOperationResult{Status: httpErr.Code, Error: httpErr.Message}
+	case !result.Executed:
+		return &batchOperationResult{Status: http.StatusFailedDependency, Error: "not executed"}
+	case !committed:
+		return &batchOperationResult{Status: http.StatusFailedDependency, Error: "rolled back"}
+	default:
+		return &batchOperationResult{ID: result.ID, Version: result.Version, Status: http.StatusOK}
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultChangesLimit = 100
+	latestChangesCursor = "latest"
+)
+
+// GetChanges godoc
+// @Summary Retrieves companies upserts and delete tombstones published after cursor, in order of publishing
+// @Description Start with empty cursor to read all retained changes or with "latest" to follow changes made
+// @Description after full synchronization. 410 means that changes after cursor are no longer retained
+// @Description and companies must be synchronized again
+// @Produce json
+// @Param   since query    string false "NextCursor from previous response, empty or latest"
+// @Param   limit query    int    false "page size (1-1000, default 100)"
+// @Success 200   {object} model.CompanyChangePage
+// @Failure 400
+// @Failure 410
+// @Failure 500
+// @Router  /company/changes [get]
+func (c *Company) GetChanges(ctx echo.Context) error {
+	request := new(companyChangesRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if request.Since == latestChangesCursor {
+		page, latestErr := c.companyService.GetLatestChanges(ctx.Request().Context())
+		if latestErr != nil {
+			return companyError(latestErr)
+		}
+		return ctx.JSON(http.StatusOK, page)
+	}
+
+	var since int64
+	if request.Since != "" {
+		since, err = strconv.ParseInt(request.Since, 10, 64)
+		if err != nil || since < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+	}
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultChangesLimit
+	}
+
+	page, err := c.companyService.GetChanges(ctx.Request().Context(), since, limit)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
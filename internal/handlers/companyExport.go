@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/xlsx"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+	exportFormatXLSX   = "xlsx"
+	mimeXLSX           = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	exportFlushRows    = 100
+)
+
+// exportColumns csv and xlsx header, names match import columns
+var exportColumns = []string{
+	"id", "name", "description", "website", "industry", "foundedYear", "headcount",
+	"address.street", "address.city", "address.region", "address.postalCode", "address.country", "parentId",
+}
+
+type companyEncoder interface {
+	encode(company *model.Company) error
+	close() error
+}
+
+// Export godoc
+// @Summary streams all companies matching filter as csv, ndjson or xlsx file
+// @Description Failure after streaming has started aborts the connection, so response without proper end is incomplete
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param   format         query string false "csv (default), ndjson or xlsx"
+// @Param   name           query string false "name filter"
+// @Param   match          query string false "prefix (default) or contains"
+// @Param   sort           query string false "name (default) or id"
+// @Param   order          query string false "asc (default) or desc"
+// @Param   tag            query []string false "tag filter, only companies having all tags (up to 10)" collectionFormat(multi)
+// @Param   updated_since  query string false "RFC 3339 time, only companies changed at or after it"
+// @Param   created_before query string false "RFC 3339 time, only companies created before it"
+// @Success 200
+// @Failure 400
+// @Router  /company/export [get]
+func (c *Company) Export(ctx echo.Context) error {
+	request := new(exportCompaniesRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	response := ctx.Response()
+	format := request.format()
+	response.Header().Set(echo.HeaderContentType, exportContentType(format))
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "companies."+format))
+	encoder, err := newCompanyEncoder(format, response)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+
+	rows := 0
+	err = c.companyService.Export(ctx.Request().Context(), request.toFilter(), func(company *model.Company) error {
+		if encodeErr := encoder.encode(company); encodeErr != nil {
+			return encodeErr
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			response.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = encoder.close()
+	}
+	if err != nil {
+		log.Error(err)
+		if !response.Committed {
+			return echo.NewHTTPError(http.StatusInternalServerError)
+		}
+		// status 200 is already sent, so the response is aborted without its end
+		// to keep client from taking truncated file for a complete one
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
+
+func exportContentType(format string) string {
+	switch format {
+	case exportFormatNDJSON:
+		return mimeNDJSON
+	case exportFormatXLSX:
+		return mimeXLSX
+	default:
+		return mimeCSV
+	}
+}
+
+func newCompanyEncoder(format string, w io.Writer) (companyEncoder, error) {
+	switch format {
+	case exportFormatNDJSON:
+		return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+	case exportFormatXLSX:
+		writer, err := xlsx.NewWriter(w, "companies")
+		if err != nil {
+			return nil, err
+		}
+		err = writer.WriteRow(cellsOf(exportColumns)...)
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxEncoder{writer: writer}, nil
+	default:
+		writer := csv.NewWriter(w)
+		err := writer.Write(exportColumns)
+		if err != nil {
+			return nil, err
+		}
+		return &csvEncoder{writer: writer}, nil
+	}
+}
+
+// exportRow returns company fields in exportColumns order
+func exportRow(company *model.Company) []interface{} {
+	return []interface{}{
+		company.ID.String(), company.Name, company.Description, company.Website, company.Industry,
+		company.FoundedYear, company.Headcount, company.Address.Street, company.Address.City,
+		company.Address.Region, company.Address.PostalCode, company.Address.Country, parentIDCell(company.ParentID),
+	}
+}
+
+func parentIDCell(parentID *uuid.UUID) string {
+	if parentID == nil {
+		return ""
+	}
+	return parentID.String()
+}
+
+func cellsOf(values []string) []interface{} {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	return cells
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (e *csvEncoder) encode(company *model.Company) error {
+	e.record = e.record[:0]
+	for _, cell := range exportRow(company) {
+		e.record = append(e.record, fmt.Sprint(cell))
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvEncoder) close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) encode(company *model.Company) error {
+	return e.encoder.Encode(company)
+}
+
+func (e *ndjsonEncoder) close() error {
+	return nil
+}
+
+type xlsxEncoder struct {
+	writer *xlsx.Writer
+}
+
+func (e *xlsxEncoder) encode(company *model.Company) error {
+	return e.writer.WriteRow(exportRow(company)...)
+}
+
+func (e *xlsxEncoder) close() error {
+	return e.writer.Close()
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultSubsidiariesDepth = 1
+
+// GetAncestors godoc
+// @Summary Retrieves parent companies chain, nearest parent first
+// @Produce json
+// @Param   id  path     string true "company uuid"
+// @Success 200 {array}  model.Company
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/ancestors [get]
+func (c *Company) GetAncestors(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	ancestors, err := c.companyService.GetAncestors(ctx.Request().Context(), id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, ancestors)
+}
+
+// GetSubsidiaries godoc
+// @Summary Retrieves company with tree of its subsidiaries
+// @Produce json
+// @Param   id    path     string true  "company uuid"
+// @Param   depth query    int    false "tree depth (1-10, default 1)"
+// @Success 200   {object} model.CompanyTree
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/subsidiaries [get]
+func (c *Company) GetSubsidiaries(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(subsidiariesRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	depth := request.Depth
+	if depth == 0 {
+		depth = defaultSubsidiariesDepth
+	}
+
+	tree, err := c.companyService.GetSubsidiaries(ctx.Request().Context(), id, depth)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, tree)
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+)
+
+const (
+	mimeCSV        = "text/csv"
+	mimeNDJSON     = "application/x-ndjson"
+	mimeNDJSONAlt  = "application/ndjson"
+	importBatch    = 500
+	maxNDJSONLine  = 1 << 20
+	ndjsonInitSize = 64 << 10
+)
+
+// importRowError validation error of imported row, rows are numbered from 1 not counting csv header
+type importRowError struct {
+	Row   int
+	Error string
+}
+
+type importReport struct {
+	DryRun   bool
+	Total    int
+	Valid    int
+	Imported int
+	Errors   []importRowError
+	// Failed valid row which couldn't be imported, import stops at it keeping rows imported before
+	Failed *importRowError `json:",omitempty"`
+}
+
+// errInvalidRow row can't be parsed but following rows can still be read
+type errInvalidRow struct {
+	err error
+}
+
+func (e *errInvalidRow) Error() string {
+	return e.err.Error()
+}
+
+type rowReader interface {
+	// read returns next row, io.EOF at the end of input and *errInvalidRow for malformed row
+	read() (*companyProfileRequest, error)
+}
+
+// Import godoc
+// @Summary import companies from CSV (with header row) or NDJSON stream, imported companies are owned by user
+// @Accept  text/csv,application/x-ndjson
+// @Produce json
+// @Param   dryRun query    bool   false "validate rows without creating companies"
+// @Param   force  query    bool   false "import rows with names similar to existing companies, rows aren't compared with each other"
+// @Param   input  body     string true  "csv rows or json objects with company profile fields"
+// @Success 200    {object} importReport
+// @Failure 400    {object} importReport "input can't be read, rows imported before are kept"
+// @Failure 409    {object} importReport "import stopped at failed row"
+// @Failure 415
+// @Failure 422    {object} importReport "import stopped at failed row"
+// @Failure 500    {object} importReport "import stopped at failed row"
+// @Router  /company/import [post]
+func (c *Company) Import(ctx echo.Context) error {
+	dryRun, err := boolQueryParam(ctx, "dryRun")
+	if err != nil {
+		return err
+	}
+	force, err := boolQueryParam(ctx, "force")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	reader, err := newRowReader(ctx.Request())
+	if err != nil {
+		return err
+	}
+
+	report := &importReport{DryRun: dryRun}
+	batch := make([]*model.Company, 0, importBatch)
+	// rows numbers of batch companies
+	rows := make([]int, 0, importBatch)
+	flush := func() error {
+		if dryRun || len(batch) == 0 {
+			batch, rows = batch[:0], rows[:0]
+			return nil
+		}
+		importErr := c.companyService.Import(ctx.Request().Context(), userID, batch)
+		if importErr != nil {
+			log.Error(importErr)
+			importErr = c.importRows(ctx, userID, batch, rows, report)
+		} else {
+			report.Imported += len(batch)
+		}
+		batch, rows = make([]*model.Company, 0, importBatch), rows[:0]
+		return importErr
+	}
+
+	for {
+		request, readErr := reader.read()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		var invalidRow *errInvalidRow
+		if readErr != nil && !errors.As(readErr, &invalidRow) {
+			log.Error(readErr)
+			report.Failed = &importRowError{Row: report.Total + 1}
+			return importFailed(ctx, report, echo.NewHTTPError(http.StatusBadRequest, readErr.Error()))
+		}
+		report.Total++
+		if readErr == nil {
+			readErr = ctx.Validate(request)
+		}
+		if readErr == nil && request.ParentID != nil {
+			readErr = c.companyService.CheckParent(ctx.Request().Context(), userID, uuid.Nil, *request.ParentID)
+			if readErr != nil && !errors.Is(readErr, model.ErrParentNotFound) && !errors.Is(readErr, model.ErrForbidden) {
+				report.Failed = &importRowError{Row: report.Total}
+				return importFailed(ctx, report, readErr)
+			}
+		}
+		var company *model.Company
+		if readErr == nil {
+			company = request.toModel()
+			if !force {
+				readErr = c.companyService.CheckDuplicates(ctx.Request().Context(), company)
+				var duplicate *model.DuplicateError
+				if readErr != nil && !errors.As(readErr, &duplicate) {
+					report.Failed = &importRowError{Row: report.Total}
+					return importFailed(ctx, report, readErr)
+				}
+			}
+		}
+		if readErr != nil {
+			report.Errors = append(report.Errors, importRowError{Row: report.Total, Error: rowErrorMessage(readErr)})
+			continue
+		}
+		report.Valid++
+
+		batch = append(batch, company)
+		rows = append(rows, report.Total)
+		if len(batch) == importBatch {
+			if err = flush(); err != nil {
+				return importFailed(ctx, report, err)
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		return importFailed(ctx, report, err)
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// importRows imports companies of failed batch one by one to find the failed row, rows before it are imported.
+// Returns error of the failed row
+func (c *Company) importRows(ctx echo.Context, userID uuid.UUID, batch []*model.Company, rows []int,
+	report *importReport) error {
+	for i, company := range batch {
+		err := c.companyService.Import(ctx.Request().Context(), userID, []*model.Company{company})
+		if err != nil {
+			report.Failed = &importRowError{Row: rows[i]}
+			return err
+		}
+		report.Imported++
+	}
+	return nil
+}
+
+// importFailed responds with report of import stopped by failed row
+func importFailed(ctx echo.Context, report *importReport, err error) error {
+	httpErr := new(echo.HTTPError)
+	if !errors.As(companyError(err), &httpErr) {
+		return err
+	}
+	if report.Failed == nil {
+		report.Failed = new(importRowError)
+	}
+	report.Failed.Error = http.StatusText(httpErr.Code)
+	if message, ok := httpErr.Message.(string); ok {
+		report.Failed.Error = message
+	}
+	return ctx.JSON(httpErr.Code, report)
+}
+
+func newRowReader(request *http.Request) (rowReader, error) {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType, "content type must be "+mimeCSV+" or "+mimeNDJSON)
+	}
+	switch mediaType {
+	case mimeCSV:
+		return newCSVRowReader(request.Body)
+	case mimeNDJSON, mimeNDJSONAlt:
+		scanner := bufio.NewScanner(request.Body)
+		scanner.Buffer(make([]byte, ndjsonInitSize), maxNDJSONLine)
+		return &ndjsonRowReader{scanner: scanner}, nil
+	default:
+		return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType, "content type must be "+mimeCSV+" or "+mimeNDJSON)
+	}
+}
+
+func rowErrorMessage(err error) string {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return fmt.Sprint(httpErr.Message)
+	}
+	return err.Error()
+}
+
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *ndjsonRowReader) read() (*companyProfileRequest, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		request := new(companyProfileRequest)
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(request); err != nil {
+			return nil, &errInvalidRow{err: err}
+		}
+		return request, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// csvColumns maps csv header names to setters of company profile fields
+func csvColumns() map[string]func(request *companyProfileRequest, value string) error {
+	return map[string]func(request *companyProfileRequest, value string) error{
+		"name":        func(r *companyProfileRequest, v string) error { r.Name = v; return nil },
+		"description": func(r *companyProfileRequest, v string) error { r.Description = v; return nil },
+		"website":     func(r *companyProfileRequest, v string) error { r.Website = v; return nil },
+		"industry":    func(r *companyProfileRequest, v string) error { r.Industry = v; return nil },
+		"foundedYear": func(r *companyProfileRequest, v string) error {
+			return parseCSVInt(v, "foundedYear", &r.FoundedYear)
+		},
+		"headcount": func(r *companyProfileRequest, v string) error {
+			return parseCSVInt(v, "headcount", &r.Headcount)
+		},
+		"address.street":     func(r *companyProfileRequest, v string) error { r.Address.Street = v; return nil },
+		"address.city":       func(r *companyProfileRequest, v string) error { r.Address.City = v; return nil },
+		"address.region":     func(r *companyProfileRequest, v string) error { r.Address.Region = v; return nil },
+		"address.postalCode": func(r *companyProfileRequest, v string) error { r.Address.PostalCode = v; return nil },
+		"address.country":    func(r *companyProfileRequest, v string) error { r.Address.Country = v; return nil },
+		"parentId": func(r *companyProfileRequest, v string) error {
+			if v == "" {
+				r.ParentID = nil
+				return nil
+			}
+			parentID, err := uuid.Parse(v)
+			if err != nil {
+				return errors.New("parentId must be uuid")
+			}
+			r.ParentID = &parentID
+			return nil
+		},
+	}
+}
+
+func parseCSVInt(value, column string, dst *int) error {
+	if value == "" {
+		*dst = 0
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s must be integer", column)
+	}
+	*dst = parsed
+	return nil
+}
+
+type csvRowReader struct {
+	reader  *csv.Reader
+	setters []func(request *companyProfileRequest, value string) error
+}
+
+func newCSVRowReader(body io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("cannot read csv header: %v", err))
+	}
+	columns := csvColumns()
+	setters := make([]func(request *companyProfileRequest, value string) error, len(header))
+	hasName := false
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		setter, ok := columns[name]
+		if !ok {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown csv column %q", name))
+		}
+		hasName = hasName || name == "name"
+		setters[i] = setter
+	}
+	if !hasName {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "csv header must contain name column")
+	}
+	return &csvRowReader{reader: reader, setters: setters}, nil
+}
+
+func (r *csvRowReader) read() (*companyProfileRequest, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &errInvalidRow{err: err}
+		}
+		return nil, err
+	}
+	if len(record) != len(r.setters) {
+		return nil, &errInvalidRow{err: fmt.Errorf("row has %d fields, header has %d", len(record), len(r.setters))}
+	}
+	request := new(companyProfileRequest)
+	for i, value := range record {
+		if err = r.setters[i](request, strings.TrimSpace(value)); err != nil {
+			return nil, &errInvalidRow{err: err}
+		}
+	}
+	return request, nil
+}
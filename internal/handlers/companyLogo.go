@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+)
+
+const (
+	// logoFormOverhead allowance for multipart headers and form fields on top of logo size limit
+	logoFormOverhead = 64 << 10
+	// logoFormMemory part of multipart form kept in memory, the rest is buffered in temporary files
+	logoFormMemory = 1 << 20
+)
+
+// GetLogoByCompanyID godoc
+// @Summary Retrieves company logo based on given company ID
+// @Produce image/png,image/jpeg,image/gif,image/webp,image/svg+xml
+// @Param   id   path  string true  "company ID"
+// @Param   size query int    false "displayed size in pixels, the smallest variant not smaller than size is returned"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/logo/{id} [get]
+func (c *Company) GetLogoByCompanyID(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	size := 0
+	if value := ctx.QueryParam("size"); value != "" {
+		size, err = strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "size must be positive integer")
+		}
+	}
+
+	logo, info, err := c.companyService.GetLogo(ctx.Request().Context(), id, size)
+	if err != nil {
+		return companyError(err)
+	}
+	defer func() {
+		if closeErr := logo.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}()
+	if info.Size >= 0 {
+		ctx.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(info.Size, 10))
+	}
+	if !info.ModTime.IsZero() {
+		ctx.Response().Header().Set(echo.HeaderLastModified, info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	ctx.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+	// sanitized svg is additionally forbidden to run anything if opened directly
+	ctx.Response().Header().Set(echo.HeaderContentSecurityPolicy, "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	return ctx.Stream(http.StatusOK, info.ContentType, logo)
+}
+
+// AddLogo godoc
+// @Summary add new company logo, accepts PNG, JPEG, GIF, WebP and SVG images
+// @Accept  mpfd
+// @Produce json
+// @Param   companyID formData string true "company ID"
+// @Param   image     formData file   true "logo image"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 413
+// @Failure 415
+// @Failure 500
+// @Router  /company/logo [post]
+func (c *Company) AddLogo(ctx echo.Context) error {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	file, err := c.logoFormFile(ctx)
+	if err != nil {
+		return err
+	}
+	companyID, err := uuid.Parse(ctx.FormValue("companyID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "companyID must be uuid")
+	}
+	err = c.companyService.AddLogo(ctx.Request().Context(), userID, companyID, file)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Logo has been added")
+}
+
+// ReplaceLogo godoc
+// @Summary upload new version of company logo, accepts PNG, JPEG, GIF, WebP and SVG images
+// @Accept  mpfd
+// @Produce json
+// @Param   id    path     string true "company ID"
+// @Param   image formData file   true "logo image"
+// @Success 200 {object} model.Logo
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 413
+// @Failure 415
+// @Failure 500
+// @Router  /company/logo/{id} [put]
+func (c *Company) ReplaceLogo(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	file, err := c.logoFormFile(ctx)
+	if err != nil {
+		return err
+	}
+	logo, err := c.companyService.ReplaceLogo(ctx.Request().Context(), userID, id, file)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, logo)
+}
+
+// DeleteLogo godoc
+// @Summary remove company logo, its versions are kept and can be reverted to
+// @Param   id path string true "company ID"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/logo/{id} [delete]
+func (c *Company) DeleteLogo(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.companyService.DeleteLogo(ctx.Request().Context(), userID, id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Logo deleted")
+}
+
+// GetLogoVersions godoc
+// @Summary list kept versions of company logo, newest first
+// @Produce json
+// @Param   id path string true "company ID"
+// @Success 200 {array} model.Logo
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router  /company/logo/{id}/versions [get]
+func (c *Company) GetLogoVersions(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	versions, err := c.companyService.GetLogoVersions(ctx.Request().Context(), userID, id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, versions)
+}
+
+// RevertLogo godoc
+// @Summary make earlier version of company logo current
+// @Produce json
+// @Param   id      path string true "company ID"
+// @Param   version path int    true "logo version"
+// @Success 200 {object} model.Logo
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/logo/{id}/versions/{version}/revert [post]
+func (c *Company) RevertLogo(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "version must be positive integer")
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	logo, err := c.companyService.RevertLogo(ctx.Request().Context(), userID, id, version)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, logo)
+}
+
+// logoFormFile parses multipart form with body limited by logo size limit and returns uploaded image
+func (c *Company) logoFormFile(ctx echo.Context) (*multipart.FileHeader, error) {
+	request := ctx.Request()
+	request.Body = http.MaxBytesReader(ctx.Response(), request.Body, c.logoMaxBytes+logoFormOverhead)
+	err := request.ParseMultipartForm(logoFormMemory)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, model.ErrFileTooLarge.Error())
+	}
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid multipart form: "+err.Error())
+	}
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "image file is required")
+	}
+	if file.Size > c.logoMaxBytes {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, model.ErrFileTooLarge.Error())
+	}
+	return file, nil
+}
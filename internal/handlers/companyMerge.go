@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Merge godoc
+// @Summary folds source company into the target one, source id becomes a redirect to target
+// @Accept  json
+// @Produce json
+// @Param   id    path     string              true "target company uuid"
+// @Param   input body     mergeCompanyRequest true "source company"
+// @Success 200   {object} model.Company
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 422
+// @Failure 500
+// @Router  /company/{id}/merge [post]
+func (c *Company) Merge(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(mergeCompanyRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if request.SourceID == id {
+		return echo.NewHTTPError(http.StatusBadRequest, "company cannot be merged into itself")
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	company, err := c.companyService.Merge(ctx.Request().Context(), userID, id, request.SourceID)
+	if err != nil {
+		return companyError(err)
+	}
+	ctx.Response().Header().Set(headerETag, etag(company.Version))
+	return ctx.JSON(http.StatusOK, company)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+// Patch godoc
+// @Summary partially update company by JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902)
+// @Accept  application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param   id       path     string true  "company uuid"
+// @Param   If-Match header   string false "entity tag of modified company"
+// @Param   force    query    bool   false "save company even if companies with similar names exist"
+// @Param   input    body     object true  "patch of company profile"
+// @Success 200      {object} model.Company
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 412
+// @Failure 415
+// @Failure 422
+// @Failure 428
+// @Failure 500
+// @Router  /company/{id} [patch]
+func (c *Company) Patch(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	version, err := ifMatchVersion(ctx, c.requireIfMatch)
+	if err != nil {
+		return err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	if err != nil || (mediaType != mimeMergePatch && mediaType != mimeJSONPatch) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			"content type must be "+mimeMergePatch+" or "+mimeJSONPatch)
+	}
+	patch, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	force, err := boolQueryParam(ctx, "force")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	company, err := c.companyService.Patch(ctx.Request().Context(), userID, id, version, force, func(company *model.Company) (*model.Company, error) {
+		return c.applyPatch(ctx, company, mediaType, patch)
+	})
+	if err != nil {
+		return companyError(err)
+	}
+
+	ctx.Response().Header().Set(headerETag, etag(company.Version))
+	return ctx.JSON(http.StatusOK, company)
+}
+
+// applyPatch applies patch to company profile and validates the result
+func (c *Company) applyPatch(ctx echo.Context, company *model.Company, mediaType string, patch []byte) (*model.Company, error) {
+	document, err := json.Marshal(newCompanyProfileRequest(company))
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	if mediaType == mimeMergePatch {
+		patched, err = jsonpatch.MergePatch(document, patch)
+	} else {
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		patched, err = operations.Apply(document)
+	}
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	request := new(companyProfileRequest)
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(request)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		return nil, err
+	}
+	return request.toModel(), nil
+}
@@ -1,13 +1,234 @@
 // Package handlers Contains rest handlers
 package handlers
 
-import "github.com/google/uuid"
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+const defaultPageLimit = 20
+
+type companyProfileRequest struct {
+	Name        string         `json:"name" validate:"required,max=256"`
+	Description string         `json:"description" validate:"max=2000"`
+	Website     string         `json:"website" validate:"omitempty,url,max=2048"`
+	Industry    string         `json:"industry" validate:"max=128"`
+	FoundedYear int            `json:"foundedYear" validate:"omitempty,min=1000,notfutureyear"`
+	Headcount   int            `json:"headcount" validate:"min=0,max=100000000"`
+	Address     addressRequest `json:"address"`
+	ParentID    *uuid.UUID     `json:"parentId"`
+}
+
+type addressRequest struct {
+	Street     string `json:"street" validate:"max=256"`
+	City       string `json:"city" validate:"required_with=Street PostalCode,max=128"`
+	Region     string `json:"region" validate:"max=128"`
+	PostalCode string `json:"postalCode" validate:"max=16"`
+	Country    string `json:"country" validate:"required_with=Street City Region PostalCode,omitempty,iso3166_1_alpha2"`
+}
 
 type addCompanyRequest struct {
-	Name string `json:"name" validate:"required"`
+	companyProfileRequest
 }
 
 type updateCompanyRequest struct {
 	UUID uuid.UUID `json:"uuid" validate:"required"`
-	Name string    `json:"name" validate:"required"`
+	companyProfileRequest
+}
+
+type batchOperationRequest struct {
+	Op      string                 `json:"op" validate:"required,oneof=create update delete"`
+	ID      uuid.UUID              `json:"id" validate:"required_unless=Op create"`
+	Version int64                  `json:"version" validate:"min=0"`
+	Force   bool                   `json:"force"`
+	Company *companyProfileRequest `json:"company" validate:"required_unless=Op delete"`
+}
+
+type batchRequest struct {
+	Mode       string                   `json:"mode" validate:"omitempty,oneof=atomic bestEffort"`
+	Operations []*batchOperationRequest `json:"operations" validate:"required,min=1,max=500,dive,required"`
+}
+
+type companyChangesRequest struct {
+	Since string `query:"since"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=1000"`
+}
+
+type pageRequest struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+type getCompaniesRequest struct {
+	Limit  int      `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string   `query:"cursor"`
+	Name   string   `query:"name" validate:"omitempty,max=256"`
+	Match  string   `query:"match" validate:"omitempty,oneof=prefix contains"`
+	Sort   string   `query:"sort" validate:"omitempty,oneof=name id"`
+	Order  string   `query:"order" validate:"omitempty,oneof=asc desc"`
+	Tags   []string `query:"tag" validate:"max=10,dive,required,max=64"`
+
+	UpdatedSince  *time.Time `query:"updated_since"`
+	CreatedBefore *time.Time `query:"created_before"`
+}
+
+type exportCompaniesRequest struct {
+	Format string   `query:"format" validate:"omitempty,oneof=csv ndjson xlsx"`
+	Name   string   `query:"name" validate:"omitempty,max=256"`
+	Match  string   `query:"match" validate:"omitempty,oneof=prefix contains"`
+	Sort   string   `query:"sort" validate:"omitempty,oneof=name id"`
+	Order  string   `query:"order" validate:"omitempty,oneof=asc desc"`
+	Tags   []string `query:"tag" validate:"max=10,dive,required,max=64"`
+
+	UpdatedSince  *time.Time `query:"updated_since"`
+	CreatedBefore *time.Time `query:"created_before"`
+}
+
+type addTagsRequest struct {
+	Tags []string `json:"tags" validate:"required,min=1,max=20,dive,required,max=64"`
+}
+
+type autocompleteTagsRequest struct {
+	Prefix string `query:"q" validate:"max=64"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type mergeCompanyRequest struct {
+	SourceID uuid.UUID `json:"sourceId" validate:"required"`
+}
+
+type subsidiariesRequest struct {
+	Depth int `query:"depth" validate:"omitempty,min=1,max=10"`
+}
+
+type searchCompaniesRequest struct {
+	Query string `query:"q" validate:"required,max=256"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+func (r *companyProfileRequest) toModel() *model.Company {
+	return &model.Company{
+		Name:        r.Name,
+		Description: r.Description,
+		Website:     r.Website,
+		Industry:    r.Industry,
+		FoundedYear: r.FoundedYear,
+		Headcount:   r.Headcount,
+		Address: model.Address{
+			Street:     r.Address.Street,
+			City:       r.Address.City,
+			Region:     r.Address.Region,
+			PostalCode: r.Address.PostalCode,
+			Country:    r.Address.Country,
+		},
+		ParentID: r.ParentID,
+	}
+}
+
+func (r *batchOperationRequest) toModel() *model.CompanyOperation {
+	operation := &model.CompanyOperation{
+		Action:  r.Op,
+		ID:      r.ID,
+		Version: r.Version,
+		Force:   r.Force,
+	}
+	if r.Company != nil {
+		operation.Company = r.Company.toModel()
+	}
+	return operation
+}
+
+func newCompanyProfileRequest(company *model.Company) *companyProfileRequest {
+	return &companyProfileRequest{
+		Name:        company.Name,
+		Description: company.Description,
+		Website:     company.Website,
+		Industry:    company.Industry,
+		FoundedYear: company.FoundedYear,
+		Headcount:   company.Headcount,
+		Address: addressRequest{
+			Street:     company.Address.Street,
+			City:       company.Address.City,
+			Region:     company.Address.Region,
+			PostalCode: company.Address.PostalCode,
+			Country:    company.Address.Country,
+		},
+		ParentID: company.ParentID,
+	}
+}
+
+func (r *getCompaniesRequest) toFilter() (*model.CompanyFilter, error) {
+	filter := &model.CompanyFilter{
+		Name:          r.Name,
+		Match:         r.Match,
+		Tags:          model.NormalizeTags(r.Tags),
+		UpdatedSince:  r.UpdatedSince,
+		CreatedBefore: r.CreatedBefore,
+		SortBy:        r.Sort,
+		Desc:          r.Order == "desc",
+		Limit:         r.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+	if r.Cursor != "" {
+		cursor, err := model.DecodeCursor(r.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.Cursor = cursor
+	}
+	return filter, nil
+}
+
+func (r *exportCompaniesRequest) toFilter() *model.CompanyFilter {
+	return &model.CompanyFilter{
+		Name:          r.Name,
+		Match:         r.Match,
+		Tags:          model.NormalizeTags(r.Tags),
+		UpdatedSince:  r.UpdatedSince,
+		CreatedBefore: r.CreatedBefore,
+		SortBy:        r.Sort,
+		Desc:          r.Order == "desc",
+	}
+}
+
+func (r *exportCompaniesRequest) format() string {
+	if r.Format == "" {
+		return exportFormatCSV
+	}
+	return r.Format
+}
+
+func (r *pageRequest) page() (cursor *model.Cursor, limit int, err error) {
+	limit = r.Limit
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if r.Cursor != "" {
+		cursor, err = model.DecodeCursor(r.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return cursor, limit, nil
+}
+
+// boolQueryParam parses optional boolean query param, absent param is false
+func boolQueryParam(ctx echo.Context, name string) (bool, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, echo.NewHTTPError(http.StatusBadRequest, name+" must be boolean")
+	}
+	return parsed, nil
 }
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultTagsLimit = 10
+
+// AddTags godoc
+// @Summary labels company by tags, tags are case insensitive
+// @Accept  json
+// @Produce json
+// @Param   id    path     string         true "company uuid"
+// @Param   input body     addTagsRequest true "tags"
+// @Success 200   {object} model.Company
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/tags [post]
+func (c *Company) AddTags(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(addTagsRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	company, err := c.companyService.AddTags(ctx.Request().Context(), userID, id, request.Tags)
+	if err != nil {
+		return companyError(err)
+	}
+	ctx.Response().Header().Set(headerETag, etag(company.Version))
+	return ctx.JSON(http.StatusOK, company)
+}
+
+// RemoveTag godoc
+// @Summary removes tag from company
+// @Produce json
+// @Param   id  path     string true "company uuid"
+// @Param   tag path     string true "tag name"
+// @Success 200 {object} model.Company
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/tags/{tag} [delete]
+func (c *Company) RemoveTag(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	company, err := c.companyService.RemoveTag(ctx.Request().Context(), userID, id, ctx.Param("tag"))
+	if err != nil {
+		return companyError(err)
+	}
+	ctx.Response().Header().Set(headerETag, etag(company.Version))
+	return ctx.JSON(http.StatusOK, company)
+}
+
+// AutocompleteTags godoc
+// @Summary Retrieves tags starting with given prefix, most used first
+// @Produce json
+// @Param   q     query string false "tag prefix"
+// @Param   limit query int    false "result size (1-100, default 10)"
+// @Success 200   {array} model.Tag
+// @Failure 400
+// @Failure 500
+// @Router  /company/tags [get]
+func (c *Company) AutocompleteTags(ctx echo.Context) error {
+	request := new(autocompleteTagsRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultTagsLimit
+	}
+
+	tags, err := c.companyService.AutocompleteTags(ctx.Request().Context(), request.Prefix, limit)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, tags)
+}
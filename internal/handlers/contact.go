@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/service"
+)
+
+// Contact handler company contacts struct
+type Contact struct {
+	contactService *service.Contact
+}
+
+// NewContact creates new company contacts handler
+func NewContact(contactService *service.Contact) *Contact {
+	return &Contact{contactService: contactService}
+}
+
+// GetAll godoc
+// @Summary Retrieves page of company contacts ordered by name
+// @Tags    contacts
+// @Produce json
+// @Param   id     path     string true  "company uuid"
+// @Param   limit  query    int    false "page size (1-100, default 20)"
+// @Param   cursor query    string false "next page cursor from previous response"
+// @Success 200    {object} model.ContactPage
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/contacts [get]
+func (c *Contact) GetAll(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(pageRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	cursor, limit, err := request.page()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.contactService.GetAll(ctx.Request().Context(), userID, companyID, cursor, limit)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// GetByID godoc
+// @Summary Retrieves company contact
+// @Tags    contacts
+// @Produce json
+// @Param   id        path     string true "company uuid"
+// @Param   contactId path     string true "contact uuid"
+// @Success 200       {object} model.Contact
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/contacts/{contactId} [get]
+func (c *Contact) GetByID(ctx echo.Context) error {
+	companyID, id, err := contactIDs(ctx)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	contact, err := c.contactService.GetByID(ctx.Request().Context(), userID, companyID, id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, contact)
+}
+
+// Create godoc
+// @Summary add contact to company
+// @Tags    contacts
+// @Accept  json
+// @Produce json
+// @Param   id    path     string         true "company uuid"
+// @Param   input body     contactRequest true "contact, email or phone in E.164 format is required"
+// @Success 200   {object} model.Contact
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/contacts [post]
+func (c *Contact) Create(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(contactRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	contact := request.toModel(companyID)
+	err = c.contactService.Create(ctx.Request().Context(), userID, contact)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, contact)
+}
+
+// Update godoc
+// @Summary update company contact
+// @Tags    contacts
+// @Accept  json
+// @Produce json
+// @Param   id        path     string         true "company uuid"
+// @Param   contactId path     string         true "contact uuid"
+// @Param   input     body     contactRequest true "contact, email or phone in E.164 format is required"
+// @Success 200       {object} model.Contact
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/contacts/{contactId} [put]
+func (c *Contact) Update(ctx echo.Context) error {
+	companyID, id, err := contactIDs(ctx)
+	if err != nil {
+		return err
+	}
+	request := new(contactRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	contact := request.toModel(companyID)
+	contact.ID = id
+	err = c.contactService.Update(ctx.Request().Context(), userID, contact)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, contact)
+}
+
+// Delete godoc
+// @Summary delete company contact
+// @Tags    contacts
+// @Param   id        path string true "company uuid"
+// @Param   contactId path string true "contact uuid"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/contacts/{contactId} [delete]
+func (c *Contact) Delete(ctx echo.Context) error {
+	companyID, id, err := contactIDs(ctx)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.contactService.Delete(ctx.Request().Context(), userID, companyID, id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Contact deleted")
+}
+
+// contactIDs parses company and contact ids from path
+func contactIDs(ctx echo.Context) (companyID, id uuid.UUID, err error) {
+	companyID, err = uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	id, err = uuid.Parse(ctx.Param("contactId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	return companyID, id, nil
+}
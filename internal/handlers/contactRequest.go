@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/model"
+)
+
+type contactRequest struct {
+	Name  string `json:"name" validate:"required,max=256"`
+	Role  string `json:"role" validate:"max=128"`
+	Email string `json:"email" validate:"required_without=Phone,omitempty,email,max=320"`
+	Phone string `json:"phone" validate:"omitempty,e164"`
+}
+
+func (r *contactRequest) toModel(companyID uuid.UUID) *model.Contact {
+	return &model.Contact{
+		CompanyID: companyID,
+		Name:      r.Name,
+		Role:      r.Role,
+		Email:     r.Email,
+		Phone:     r.Phone,
+	}
+}
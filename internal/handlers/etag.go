@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+	anyETag           = "*"
+)
+
+// etag formats entity version as strong entity tag
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion returns version required by If-Match header, 0 means any version
+func ifMatchVersion(ctx echo.Context, required bool) (int64, error) {
+	header := strings.TrimSpace(ctx.Request().Header.Get(headerIfMatch))
+	if header == "" {
+		if required {
+			return 0, echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match header is required")
+		}
+		return 0, nil
+	}
+	if header == anyETag {
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "If-Match header must contain single strong entity tag")
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed)
+	}
+	return version, nil
+}
+
+// ifNoneMatch reports whether If-None-Match header matches entity version
+func ifNoneMatch(ctx echo.Context, version int64) bool {
+	header := ctx.Request().Header.Get(headerIfNoneMatch)
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == anyETag || tag == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/service"
+)
+
+// Member handler company membership struct
+type Member struct {
+	memberService *service.Member
+}
+
+// NewMember creates new company membership handler
+func NewMember(memberService *service.Member) *Member {
+	return &Member{memberService: memberService}
+}
+
+// GetAll godoc
+// @Summary Retrieves members of company
+// @Tags    members
+// @Produce json
+// @Param   id  path  string true "company uuid"
+// @Success 200 {array} model.CompanyMember
+// @Failure 400
+// @Failure 403
+// @Failure 500
+// @Router  /company/{id}/members [get]
+func (m *Member) GetAll(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	actorID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	members, err := m.memberService.GetMembers(ctx.Request().Context(), actorID, companyID)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, members)
+}
+
+// Grant godoc
+// @Summary grant role in company to user
+// @Tags    members
+// @Accept  json
+// @Produce json
+// @Param   id     path     string             true "company uuid"
+// @Param   userId path     string             true "user uuid"
+// @Param   input  body     grantMemberRequest true "role: owner, editor or viewer"
+// @Success 200    {object} model.CompanyMember
+// @Failure 400
+// @Failure 403
+// @Failure 409
+// @Failure 500
+// @Router  /company/{id}/members/{userId} [put]
+func (m *Member) Grant(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(grantMemberRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	actorID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	member, err := m.memberService.Grant(ctx.Request().Context(), actorID, companyID, userID, request.Role)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, member)
+}
+
+// Revoke godoc
+// @Summary revoke user access to company
+// @Tags    members
+// @Produce json
+// @Param   id     path string true "company uuid"
+// @Param   userId path string true "user uuid"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router  /company/{id}/members/{userId} [delete]
+func (m *Member) Revoke(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	actorID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = m.memberService.Revoke(ctx.Request().Context(), actorID, companyID, userID)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Access revoked")
+}
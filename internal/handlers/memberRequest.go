@@ -0,0 +1,5 @@
+package handlers
+
+type grantMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
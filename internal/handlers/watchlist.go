@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/service"
+)
+
+// Watchlist handler followed companies and notifications struct
+type Watchlist struct {
+	watchlistService *service.Watchlist
+}
+
+// NewWatchlist creates new watchlist handler
+func NewWatchlist(watchlistService *service.Watchlist) *Watchlist {
+	return &Watchlist{watchlistService: watchlistService}
+}
+
+// GetAll godoc
+// @Summary Retrieves page of companies watched by current user ordered by name
+// @Tags    watchlist
+// @Produce json
+// @Param   limit  query    int    false "page size (1-100, default 20)"
+// @Param   cursor query    string false "next page cursor from previous response"
+// @Success 200    {object} model.CompanyPage
+// @Failure 400
+// @Failure 500
+// @Router  /watchlist [get]
+func (w *Watchlist) GetAll(ctx echo.Context) error {
+	cursor, limit, err := bindPage(ctx)
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := w.watchlistService.GetCompanies(ctx.Request().Context(), userID, cursor, limit)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// Watch godoc
+// @Summary add company to watchlist of current user
+// @Tags    watchlist
+// @Param   id  path string true "company uuid"
+// @Success 200
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router  /watchlist/{id} [put]
+func (w *Watchlist) Watch(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = w.watchlistService.Watch(ctx.Request().Context(), userID, companyID)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Company watched")
+}
+
+// Unwatch godoc
+// @Summary remove company from watchlist of current user
+// @Tags    watchlist
+// @Param   id  path string true "company uuid"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /watchlist/{id} [delete]
+func (w *Watchlist) Unwatch(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = w.watchlistService.Unwatch(ctx.Request().Context(), userID, companyID)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Company unwatched")
+}
+
+// GetNotifications godoc
+// @Summary Retrieves page of notifications about changes of watched companies, newest first
+// @Tags    watchlist
+// @Produce json
+// @Param   unread query    bool   false "only unread notifications"
+// @Param   limit  query    int    false "page size (1-100, default 20)"
+// @Param   cursor query    string false "next page cursor from previous response"
+// @Success 200    {object} model.NotificationPage
+// @Failure 400
+// @Failure 500
+// @Router  /notifications [get]
+func (w *Watchlist) GetNotifications(ctx echo.Context) error {
+	request := new(getNotificationsRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	cursor, limit, err := request.page()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := w.watchlistService.GetNotifications(ctx.Request().Context(), userID, request.Unread, cursor, limit)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// MarkRead godoc
+// @Summary mark notification of current user as read
+// @Tags    watchlist
+// @Param   id  path string true "notification uuid"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /notifications/{id}/read [post]
+func (w *Watchlist) MarkRead(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = w.watchlistService.MarkRead(ctx.Request().Context(), userID, id)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Notification read")
+}
+
+// MarkAllRead godoc
+// @Summary mark all notifications of current user as read
+// @Tags    watchlist
+// @Produce json
+// @Success 200 {object} markReadResponse
+// @Failure 500
+// @Router  /notifications/read [post]
+func (w *Watchlist) MarkAllRead(ctx echo.Context) error {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	marked, err := w.watchlistService.MarkAllRead(ctx.Request().Context(), userID)
+	if err != nil {
+		return companyError(err)
+	}
+	return ctx.JSON(http.StatusOK, &markReadResponse{Marked: marked})
+}
@@ -0,0 +1,10 @@
+package handlers
+
+type getNotificationsRequest struct {
+	pageRequest
+	Unread bool `query:"unread"`
+}
+
+type markReadResponse struct {
+	Marked int64
+}
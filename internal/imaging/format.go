@@ -0,0 +1,53 @@
+// Package imaging contains detection and processing of uploaded images
+package imaging
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// Supported image content types
+const (
+	PNG  = "image/png"
+	JPEG = "image/jpeg"
+	GIF  = "image/gif"
+	WebP = "image/webp"
+	SVG  = "image/svg+xml"
+)
+
+// svgSniffLen size of content beginning searched for svg root element
+const svgSniffLen = 1024
+
+var extensions = map[string]string{
+	PNG:  ".png",
+	JPEG: ".jpeg",
+	GIF:  ".gif",
+	WebP: ".webp",
+	SVG:  ".svg",
+}
+
+// ContentType sniffs content type of image from its content ignoring client supplied type,
+// returns empty string for unsupported content. SVG is only a candidate until SanitizeSVG accepts it
+func ContentType(data []byte) string {
+	detected := http.DetectContentType(data)
+	switch detected {
+	case PNG, JPEG, GIF, WebP:
+		return detected
+	}
+	if strings.HasPrefix(detected, "text/xml") || strings.HasPrefix(detected, "text/plain") {
+		head := data
+		if len(head) > svgSniffLen {
+			head = head[:svgSniffLen]
+		}
+		if bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+			return SVG
+		}
+	}
+	return ""
+}
+
+// Extension file extension of supported content type
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
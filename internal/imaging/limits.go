@@ -0,0 +1,32 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+)
+
+// ErrTooLarge image dimensions exceed allowed limits
+var ErrTooLarge = errors.New("image dimensions exceed allowed limits")
+
+// CheckDimensions reads only the header of raster image and verifies its dimensions before the image is decoded,
+// so that small compressed files expanding into huge bitmaps (decompression bombs) are rejected cheaply.
+// SVG images have no pixel dimensions and are always accepted
+func CheckDimensions(data []byte, contentType string, maxDimension, maxPixels int) error {
+	if contentType == SVG {
+		return nil
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return fmt.Errorf("%w: empty image", ErrCorruptImage)
+	}
+	if config.Width > maxDimension || config.Height > maxDimension ||
+		int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+	return nil
+}
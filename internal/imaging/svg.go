@@ -0,0 +1,227 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotSVG content isn't well-formed svg document
+var ErrNotSVG = errors.New("content is not a valid svg image")
+
+// maxSVGDepth maximum nesting of svg elements
+const maxSVGDepth = 256
+
+const (
+	svgNamespace   = "http://www.w3.org/2000/svg"
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+)
+
+// allowedElements elements which are kept, others are dropped together with their content
+var allowedElements = nameSet(
+	"svg", "g", "defs", "symbol", "use", "title", "desc", "a", "switch", "style",
+	"path", "rect", "circle", "ellipse", "line", "polyline", "polygon", "text", "tspan", "textPath",
+	"linearGradient", "radialGradient", "stop", "pattern", "clipPath", "mask", "marker",
+	"filter", "feBlend", "feColorMatrix", "feComponentTransfer", "feComposite", "feConvolveMatrix",
+	"feDiffuseLighting", "feDisplacementMap", "feDistantLight", "feDropShadow", "feFlood", "feFuncA", "feFuncB",
+	"feFuncG", "feFuncR", "feGaussianBlur", "feMerge", "feMergeNode", "feMorphology", "feOffset", "fePointLight",
+	"feSpecularLighting", "feSpotLight", "feTile", "feTurbulence",
+	"animate", "animateMotion", "animateTransform", "set", "mpath",
+)
+
+// animationElements elements changing attribute named by their attributeName
+var animationElements = nameSet("animate", "animateMotion", "animateTransform", "set")
+
+// allowedAttributes attributes without namespace prefix which are kept, others are dropped
+var allowedAttributes = nameSet(
+	"id", "class", "style", "href", "x", "y", "x1", "y1", "x2", "y2", "cx", "cy", "r", "rx", "ry", "fx", "fy", "fr",
+	"width", "height", "d", "points", "pathLength", "transform", "viewBox", "preserveAspectRatio", "version",
+	"baseProfile", "lang",
+	"fill", "fill-opacity", "fill-rule", "stroke", "stroke-width", "stroke-opacity", "stroke-linecap",
+	"stroke-linejoin", "stroke-miterlimit", "stroke-dasharray", "stroke-dashoffset", "opacity", "color", "display",
+	"visibility", "overflow", "clip-path", "clip-rule", "mask", "filter", "marker-start", "marker-mid", "marker-end",
+	"vector-effect", "shape-rendering", "image-rendering", "color-interpolation", "color-interpolation-filters",
+	"paint-order", "mix-blend-mode", "isolation", "flood-color", "flood-opacity", "lighting-color",
+	"font-family", "font-size", "font-weight", "font-style", "font-variant", "text-anchor", "dominant-baseline",
+	"alignment-baseline", "baseline-shift", "letter-spacing", "word-spacing", "text-decoration", "writing-mode",
+	"direction", "dx", "dy", "rotate", "textLength", "lengthAdjust", "startOffset",
+	"stop-color", "stop-opacity", "offset", "gradientUnits", "gradientTransform", "spreadMethod",
+	"patternUnits", "patternContentUnits", "patternTransform", "clipPathUnits", "maskUnits", "maskContentUnits",
+	"markerWidth", "markerHeight", "markerUnits", "refX", "refY", "orient",
+	"filterUnits", "primitiveUnits", "in", "in2", "result", "stdDeviation", "mode", "type", "values", "operator",
+	"k1", "k2", "k3", "k4", "scale", "xChannelSelector", "yChannelSelector", "radius", "edgeMode", "order",
+	"kernelMatrix", "divisor", "bias", "targetX", "targetY", "preserveAlpha", "surfaceScale", "specularConstant",
+	"specularExponent", "diffuseConstant", "azimuth", "elevation", "pointsAtX", "pointsAtY", "pointsAtZ",
+	"limitingConeAngle", "tableValues", "slope", "intercept", "amplitude", "exponent", "baseFrequency",
+	"numOctaves", "seed", "stitchTiles",
+	"attributeName", "attributeType", "from", "to", "by", "begin", "dur", "end", "repeatCount", "repeatDur",
+	"calcMode", "keyTimes", "keySplines", "keyPoints", "additive", "accumulate", "restart", "path", "min", "max",
+)
+
+func nameSet(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
+
+// SanitizeSVG re-serializes svg document keeping only allowed drawing elements and attributes, so that nothing
+// can run scripts or load external resources. References must be local, animations can't change references or
+// styles, css with escapes is dropped. Comments, processing instructions and DTD are left out
+func SanitizeSVG(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	var out bytes.Buffer
+	var stack []xml.Name
+	// skip depth of element being dropped, 0 when nothing is dropped
+	skip := 0
+	// css content of style element being read, it is checked as a whole because text can be split by CDATA sections
+	var css *bytes.Buffer
+	rootClosed := false
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotSVG, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if rootClosed || len(stack) == 0 && (t.Name.Space != "" || t.Name.Local != "svg") {
+				return nil, ErrNotSVG
+			}
+			if len(stack) >= maxSVGDepth {
+				return nil, fmt.Errorf("%w: elements are nested deeper than %d", ErrNotSVG, maxSVGDepth)
+			}
+			stack = append(stack, t.Name)
+			if skip > 0 || css != nil || !safeElement(t) {
+				skip++
+				continue
+			}
+			if t.Name.Local == "style" {
+				css = new(bytes.Buffer)
+			}
+			writeStart(&out, t)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1] != t.Name {
+				return nil, ErrNotSVG
+			}
+			stack = stack[:len(stack)-1]
+			rootClosed = len(stack) == 0
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if css != nil {
+				if !unsafeCSS(css.String()) {
+					_ = xml.EscapeText(&out, css.Bytes())
+				}
+				css = nil
+			}
+			fmt.Fprintf(&out, "</%s>", qualifiedName(t.Name))
+		case xml.CharData:
+			if len(stack) == 0 {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, ErrNotSVG
+				}
+				continue
+			}
+			switch {
+			case skip > 0:
+			case css != nil:
+				css.Write(t)
+			default:
+				_ = xml.EscapeText(&out, t)
+			}
+		}
+	}
+	if !rootClosed {
+		return nil, ErrNotSVG
+	}
+	return out.Bytes(), nil
+}
+
+func writeStart(out *bytes.Buffer, element xml.StartElement) {
+	fmt.Fprintf(out, "<%s", qualifiedName(element.Name))
+	for _, attr := range element.Attr {
+		if !safeAttr(attr) {
+			continue
+		}
+		fmt.Fprintf(out, ` %s="`, qualifiedName(attr.Name))
+		_ = xml.EscapeText(out, []byte(attr.Value))
+		out.WriteByte('"')
+	}
+	out.WriteByte('>')
+}
+
+// safeElement reports whether element is allowed and doesn't animate attributes which aren't allowed
+func safeElement(element xml.StartElement) bool {
+	if element.Name.Space != "" || !allowedElements[element.Name.Local] {
+		return false
+	}
+	if !animationElements[element.Name.Local] {
+		return true
+	}
+	for _, attr := range element.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "attributeName" {
+			// attributeName can carry namespace prefix as xlink:href does
+			target := attr.Value[strings.LastIndex(attr.Value, ":")+1:]
+			if target == "href" || target == "style" || !allowedAttributes[target] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func safeAttr(attr xml.Attr) bool {
+	value := strings.ToLower(strings.Join(strings.Fields(attr.Value), ""))
+	switch {
+	case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+		return attr.Value == svgNamespace
+	case attr.Name.Space == "xmlns":
+		return attr.Name.Local == "xlink" && attr.Value == xlinkNamespace
+	case attr.Name.Space == "xml":
+		return attr.Name.Local == "space" || attr.Name.Local == "lang"
+	case attr.Name.Space == "xlink" && attr.Name.Local == "href", attr.Name.Space == "" && attr.Name.Local == "href":
+		// only references to elements of the same document
+		return strings.HasPrefix(value, "#")
+	case attr.Name.Space != "" || !allowedAttributes[attr.Name.Local]:
+		return false
+	}
+	// presentation attributes and animation values take css values such as url() too
+	return !strings.Contains(value, "javascript:") && !unsafeCSS(value)
+}
+
+// unsafeCSS reports whether css can run scripts or load external resources. Css with escapes is rejected
+// because browsers unescape names like u\72l( which can't be matched here
+func unsafeCSS(css string) bool {
+	css = strings.ToLower(strings.Join(strings.Fields(css), ""))
+	if strings.Contains(css, "\\") || strings.Contains(css, "javascript:") || strings.Contains(css, "expression(") ||
+		strings.Contains(css, "@import") || strings.Contains(css, "image-set(") {
+		return true
+	}
+	for rest := css; ; {
+		i := strings.Index(rest, "url(")
+		if i < 0 {
+			return false
+		}
+		rest = strings.TrimLeft(rest[i+len("url("):], `"'`)
+		if !strings.HasPrefix(rest, "#") {
+			return true
+		}
+	}
+}
+
+// qualifiedName name with its raw namespace prefix as written in the document
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,168 @@
+package imaging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		name    string
+		svg     string
+		keep    []string
+		dropped []string
+	}{
+		{
+			name: "drawing is kept",
+			svg: `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><defs><linearGradient id="g">` +
+				`<stop offset="0" stop-color="red"/></linearGradient></defs><rect width="10" height="10" fill="url(#g)"/></svg>`,
+			keep: []string{`xmlns="http://www.w3.org/2000/svg"`, `<linearGradient id="g">`, `fill="url(#g)"`},
+		},
+		{
+			name:    "script",
+			svg:     `<svg><script>alert(1)</script><g/></svg>`,
+			keep:    []string{"<g>"},
+			dropped: []string{"script", "alert"},
+		},
+		{
+			name:    "unknown element is dropped with content",
+			svg:     `<svg><foreignObject><div>text</div></foreignObject><image href="http://evil/x.png"/></svg>`,
+			dropped: []string{"foreignObject", "div", "text", "image", "evil"},
+		},
+		{
+			name:    "namespaced element",
+			svg:     `<svg xmlns:h="http://www.w3.org/1999/xhtml"><h:script>alert(1)</h:script></svg>`,
+			dropped: []string{"script", "alert", "xhtml"},
+		},
+		{
+			name:    "event handler",
+			svg:     `<svg onload="alert(1)"><rect onclick="alert(2)" ONMOUSEOVER="alert(3)" width="1"/></svg>`,
+			keep:    []string{`width="1"`},
+			dropped: []string{"alert"},
+		},
+		{
+			name:    "unknown attribute",
+			svg:     `<svg><rect data-x="1" formaction="javascript:alert(1)"/></svg>`,
+			dropped: []string{"data-x", "formaction"},
+		},
+		{
+			name: "external references",
+			svg: `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><a href="javascript:alert(1)"><use xlink:href="http://evil/x.svg#a"/>` +
+				`<use href="#a"/></a></svg>`,
+			keep:    []string{`<use href="#a">`, `xmlns:xlink="http://www.w3.org/1999/xlink"`},
+			dropped: []string{"javascript", "evil"},
+		},
+		{
+			name:    "animated href",
+			svg:     `<svg><a><animate attributeName="href" to="data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;"/><text>x</text></a></svg>`,
+			keep:    []string{"<text>x</text>"},
+			dropped: []string{"animate", "data:"},
+		},
+		{
+			name:    "animated prefixed href",
+			svg:     `<svg><a><set attributeName="xlink:href" to="javascript:alert(1)"/></a></svg>`,
+			dropped: []string{"set", "javascript"},
+		},
+		{
+			name:    "animated event handler",
+			svg:     `<svg><animateMotion attributeName="onbegin" values="alert(1)"/><set attributeName="onclick" to="alert(1)"/></svg>`,
+			dropped: []string{"animateMotion", "set", "alert"},
+		},
+		{
+			name:    "animated style",
+			svg:     `<svg><rect><set attributeName="style" to="fill:red"/></rect></svg>`,
+			dropped: []string{"set"},
+		},
+		{
+			name: "animation of presentation attribute",
+			svg:  `<svg><rect><animate attributeName="fill" from="red" to="blue" dur="1s"/></rect></svg>`,
+			keep: []string{`<animate attributeName="fill" from="red" to="blue" dur="1s">`},
+		},
+		{
+			name:    "animation to external resource",
+			svg:     `<svg><rect><animate attributeName="fill" to="url(http://evil/p.svg#p)"/></rect></svg>`,
+			keep:    []string{`<animate attributeName="fill">`},
+			dropped: []string{"evil"},
+		},
+		{
+			name:    "style attribute",
+			svg:     `<svg><rect style="fill:red"/><rect style="background:url(http://evil/x.png)"/><rect style="fill:expression(alert(1))"/></svg>`,
+			keep:    []string{`style="fill:red"`},
+			dropped: []string{"evil", "expression"},
+		},
+		{
+			name:    "escaped url in style attribute",
+			svg:     `<svg><rect style="background:u\72l(http://evil/x.png)"/></svg>`,
+			dropped: []string{"evil"},
+		},
+		{
+			name: "style element",
+			svg:  `<svg><style>rect{fill:red}</style></svg>`,
+			keep: []string{"<style>rect{fill:red}</style>"},
+		},
+		{
+			name:    "escaped import in style element",
+			svg:     `<svg><style>@\69mport "http://evil/x.css";</style></svg>`,
+			keep:    []string{"<style></style>"},
+			dropped: []string{"evil"},
+		},
+		{
+			name:    "style element split by CDATA",
+			svg:     `<svg><style>rect{background:u<![CDATA[rl(http://evil/x.png)}]]></style></svg>`,
+			dropped: []string{"evil"},
+		},
+		{
+			name: "element inside style",
+			svg:  `<svg><style><g>x</g></style></svg>`,
+			keep: []string{"<style></style>"},
+		},
+		{
+			name:    "comments, processing instructions and doctype",
+			svg:     `<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY e "x">]><svg><!-- c --><?pi x?><g/></svg>`,
+			keep:    []string{"<svg><g></g></svg>"},
+			dropped: []string{"DOCTYPE", "ENTITY", "<!--", "<?"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := SanitizeSVG([]byte(test.svg))
+			if err != nil {
+				t.Fatalf("SanitizeSVG() error = %v", err)
+			}
+			for _, keep := range test.keep {
+				if !strings.Contains(string(out), keep) {
+					t.Errorf("SanitizeSVG() = %s, want it to contain %s", out, keep)
+				}
+			}
+			for _, dropped := range test.dropped {
+				if strings.Contains(string(out), dropped) {
+					t.Errorf("SanitizeSVG() = %s, want %s dropped", out, dropped)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeSVGInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+	}{
+		{name: "not xml", svg: "svg"},
+		{name: "other root", svg: `<html><svg/></html>`},
+		{name: "prefixed root", svg: `<s:svg xmlns:s="http://www.w3.org/2000/svg"/>`},
+		{name: "unclosed", svg: `<svg><g>`},
+		{name: "second root", svg: `<svg/><svg/>`},
+		{name: "text after root", svg: `<svg/>text`},
+		{name: "too deep", svg: "<svg>" + strings.Repeat("<g>", maxSVGDepth) + strings.Repeat("</g>", maxSVGDepth) + "</svg>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := SanitizeSVG([]byte(test.svg))
+			if !errors.Is(err, ErrNotSVG) {
+				t.Errorf("SanitizeSVG() error = %v, want %v", err, ErrNotSVG)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	// registers gif decoder for image.Decode
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	// registers webp decoder for image.Decode
+	_ "golang.org/x/image/webp"
+)
+
+const thumbnailQuality = 90
+
+// ErrCorruptImage content has supported type but can't be decoded
+var ErrCorruptImage = errors.New("image cannot be decoded")
+
+// Thumbnail downscaled copy of an image
+type Thumbnail struct {
+	// Dimension size of bounding square the thumbnail was fitted into
+	Dimension   int
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Thumbnails fits raster image into squares of given dimensions preserving aspect ratio. Images are never upscaled,
+// so dimensions not smaller than the image are skipped, svg images have no thumbnails at all.
+// JPEG thumbnails stay JPEG, other formats become PNG to keep transparency
+func Thumbnails(data []byte, contentType string, dimensions []int) ([]*Thumbnail, error) {
+	if contentType == SVG {
+		return nil, nil
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptImage, err)
+	}
+	thumbnailType := PNG
+	if contentType == JPEG {
+		thumbnailType = JPEG
+	}
+	bounds := src.Bounds()
+	var thumbnails []*Thumbnail
+	for _, dimension := range dimensions {
+		if dimension <= 0 || dimension >= bounds.Dx() && dimension >= bounds.Dy() {
+			continue
+		}
+		width, height := fit(bounds.Dx(), bounds.Dy(), dimension)
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if thumbnailType == JPEG {
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality})
+		} else {
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode thumbnail: %v", err)
+		}
+		thumbnails = append(thumbnails, &Thumbnail{
+			Dimension:   dimension,
+			Width:       width,
+			Height:      height,
+			ContentType: thumbnailType,
+			Data:        buf.Bytes(),
+		})
+	}
+	return thumbnails, nil
+}
+
+// fit scales width and height so that the longer side equals dimension
+func fit(width, height, dimension int) (int, int) {
+	if width >= height {
+		return dimension, atLeastOne(height * dimension / width)
+	}
+	return atLeastOne(width * dimension / height), dimension
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
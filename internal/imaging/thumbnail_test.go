@@ -0,0 +1,119 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height, dimension int
+		wantWidth, wantHeight    int
+	}{
+		{width: 400, height: 200, dimension: 100, wantWidth: 100, wantHeight: 50},
+		{width: 200, height: 400, dimension: 100, wantWidth: 50, wantHeight: 100},
+		{width: 300, height: 300, dimension: 64, wantWidth: 64, wantHeight: 64},
+		{width: 1000, height: 3, dimension: 100, wantWidth: 100, wantHeight: 1},
+		{width: 3, height: 1000, dimension: 100, wantWidth: 1, wantHeight: 100},
+		{width: 333, height: 200, dimension: 128, wantWidth: 128, wantHeight: 76},
+	}
+	for _, test := range tests {
+		width, height := fit(test.width, test.height, test.dimension)
+		if width != test.wantWidth || height != test.wantHeight {
+			t.Errorf("fit(%d, %d, %d) = %d, %d, want %d, %d", test.width, test.height, test.dimension,
+				width, height, test.wantWidth, test.wantHeight)
+		}
+	}
+}
+
+func encodeTestImage(t *testing.T, width, height int, contentType string) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if contentType == JPEG {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnails(t *testing.T) {
+	data := encodeTestImage(t, 300, 150, PNG)
+	thumbnails, err := Thumbnails(data, PNG, []int{64, 0, 128, 300, 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Thumbnail{
+		{Dimension: 64, Width: 64, Height: 32, ContentType: PNG},
+		{Dimension: 128, Width: 128, Height: 64, ContentType: PNG},
+	}
+	if len(thumbnails) != len(want) {
+		t.Fatalf("got %d thumbnails, want %d: dimensions not smaller than the image must be skipped",
+			len(thumbnails), len(want))
+	}
+	for i, thumbnail := range thumbnails {
+		if thumbnail.Dimension != want[i].Dimension || thumbnail.Width != want[i].Width ||
+			thumbnail.Height != want[i].Height || thumbnail.ContentType != want[i].ContentType {
+			t.Errorf("thumbnail %d = %dx%d in %d %s, want %dx%d in %d %s", i, thumbnail.Width, thumbnail.Height,
+				thumbnail.Dimension, thumbnail.ContentType, want[i].Width, want[i].Height, want[i].Dimension,
+				want[i].ContentType)
+		}
+		decoded, format, decodeErr := image.Decode(bytes.NewReader(thumbnail.Data))
+		if decodeErr != nil {
+			t.Fatalf("thumbnail %d cannot be decoded: %v", i, decodeErr)
+		}
+		if format != "png" || decoded.Bounds().Dx() != thumbnail.Width || decoded.Bounds().Dy() != thumbnail.Height {
+			t.Errorf("thumbnail %d is %s %v", i, format, decoded.Bounds())
+		}
+	}
+}
+
+func TestThumbnailsNeverUpscale(t *testing.T) {
+	// the longer side decides, a dimension between the sides would upscale the shorter one
+	data := encodeTestImage(t, 100, 40, PNG)
+	thumbnails, err := Thumbnails(data, PNG, []int{50, 100, 120})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thumbnails) != 1 || thumbnails[0].Dimension != 50 {
+		t.Fatalf("thumbnails = %+v, want only 50", thumbnails)
+	}
+}
+
+func TestThumbnailsFormat(t *testing.T) {
+	thumbnails, err := Thumbnails(encodeTestImage(t, 200, 200, JPEG), JPEG, []int{64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thumbnails) != 1 || thumbnails[0].ContentType != JPEG {
+		t.Fatalf("jpeg thumbnails = %+v, want jpeg", thumbnails)
+	}
+	if _, format, _ := image.Decode(bytes.NewReader(thumbnails[0].Data)); format != "jpeg" {
+		t.Errorf("jpeg thumbnail is encoded as %s", format)
+	}
+
+	thumbnails, err = Thumbnails([]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), SVG, []int{64})
+	if err != nil || thumbnails != nil {
+		t.Errorf("svg thumbnails = %+v, %v, want none", thumbnails, err)
+	}
+
+	_, err = Thumbnails([]byte("not an image"), PNG, []int{64})
+	if !errors.Is(err, ErrCorruptImage) {
+		t.Errorf("corrupt image error = %v, want ErrCorruptImage", err)
+	}
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"errors"
+
+	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
@@ -14,3 +17,34 @@ func NewJwtMiddleware(accessTokenKey string) echo.MiddlewareFunc {
 		Claims:     new(model.Claim),
 	})
 }
+
+// NewAdminMiddleware creates middleware which passes only administrators listed in adminUserIDs
+func NewAdminMiddleware(adminUserIDs []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			claim, err := GetClaim(ctx)
+			if err != nil {
+				return echo.ErrUnauthorized
+			}
+			for _, id := range adminUserIDs {
+				if id == claim.UserID {
+					return next(ctx)
+				}
+			}
+			return echo.ErrForbidden
+		}
+	}
+}
+
+// GetClaim returns claim of access token validated by jwt middleware
+func GetClaim(ctx echo.Context) (*model.Claim, error) {
+	token, ok := ctx.Get("user").(*jwt.Token)
+	if !ok {
+		return nil, errors.New("access token is missing")
+	}
+	claim, ok := token.Claims.(*model.Claim)
+	if !ok {
+		return nil, errors.New("unexpected access token claims")
+	}
+	return claim, nil
+}
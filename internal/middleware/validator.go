@@ -3,9 +3,11 @@ package middleware
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
 )
 
 // CustomValidator validation middleware
@@ -15,6 +17,10 @@ type CustomValidator struct {
 
 // NewCustomValidator creates CustomValidator object
 func NewCustomValidator(v *validator.Validate) *CustomValidator {
+	err := v.RegisterValidation("notfutureyear", notFutureYear)
+	if err != nil {
+		log.Fatal(err)
+	}
 	return &CustomValidator{
 		Validator: v,
 	}
@@ -27,3 +33,8 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	}
 	return nil
 }
+
+// notFutureYear checks that year field is not after the current year
+func notFutureYear(fl validator.FieldLevel) bool {
+	return fl.Field().Int() <= int64(time.Now().Year())
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment note on company, ParentID is set for replies. Deleted comments are kept
+// without body and mentions so that threads stay intact
+type Comment struct {
+	ID        uuid.UUID   `bson:"_id"`
+	CompanyID uuid.UUID   `bson:"company_id"`
+	ParentID  *uuid.UUID  `bson:"parent_id"`
+	AuthorID  uuid.UUID   `bson:"author_id"`
+	Body      string      `bson:"body"`
+	Mentions  []uuid.UUID `bson:"mentions"`
+	Replies   int         `bson:"replies"`
+	CreatedAt time.Time   `bson:"created_at"`
+	EditedAt  *time.Time  `bson:"edited_at"`
+	DeletedAt *time.Time  `bson:"deleted_at"`
+}
+
+// CommentPage one page of comments in chronological order
+type CommentPage struct {
+	Items      []*Comment
+	NextCursor string
+}
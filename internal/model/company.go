@@ -1,10 +1,94 @@
 // Package model domain models package
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SortByName sort companies by name
+	SortByName = "name"
+	// SortByID sort companies by id
+	SortByID = "id"
+	// MatchPrefix match companies which names start with filter value
+	MatchPrefix = "prefix"
+	// MatchContains match companies which names contain filter value
+	MatchContains = "contains"
+	// MaxHierarchyDepth limit of company hierarchy traversal
+	MaxHierarchyDepth = 100
+)
 
 // Company domain company struct
 type Company struct {
-	ID   uuid.UUID `bson:"_id"`
-	Name string    `bson:"name"`
+	ID          uuid.UUID  `bson:"_id"`
+	Name        string     `bson:"name"`
+	Description string     `bson:"description"`
+	Website     string     `bson:"website"`
+	Industry    string     `bson:"industry"`
+	FoundedYear int        `bson:"founded_year"`
+	Headcount   int        `bson:"headcount"`
+	Address     Address    `bson:"address"`
+	ParentID    *uuid.UUID `bson:"parent_id"`
+	Tags        []string   `bson:"tags,omitempty"`
+	DeletedAt   *time.Time `bson:"deleted_at,omitempty"`
+	Version     int64      `bson:"version"`
+	CreatedBy   uuid.UUID  `bson:"created_by"`
+	CreatedAt   time.Time  `bson:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at"`
+
+	// NormalizedName name used for duplicate detection, see NormalizeCompanyName
+	NormalizedName string `bson:"normalized_name" json:"-"`
+}
+
+// Address company postal address
+type Address struct {
+	Street     string `bson:"street"`
+	City       string `bson:"city"`
+	Region     string `bson:"region"`
+	PostalCode string `bson:"postal_code"`
+	Country    string `bson:"country"`
+}
+
+// CompanyTree company with its subsidiaries
+type CompanyTree struct {
+	Company
+	Subsidiaries []*CompanyTree
+}
+
+// CompanyMerge records changed by merge of companies besides the merged ones
+type CompanyMerge struct {
+	// Subsidiaries former subsidiaries of source company moved to target
+	Subsidiaries []*Company
+}
+
+// CompanyFilter company listing filter, sorting and pagination params. UpdatedSince is inclusive,
+// CreatedBefore is exclusive
+type CompanyFilter struct {
+	Cursor        *Cursor
+	Name          string
+	Match         string
+	Tags          []string
+	UpdatedSince  *time.Time
+	CreatedBefore *time.Time
+	SortBy        string
+	Desc          bool
+	Deleted       bool
+	Limit         int
+}
+
+// CompanyPage one page of companies listing
+type CompanyPage struct {
+	Items      []*Company
+	Total      int64
+	NextCursor string
+}
+
+// CompanySearchResult company found by search with its relevance and highlighted name
+type CompanySearchResult struct {
+	Company
+	Rank float64
+	// Highlight HTML escaped name with matches wrapped into <mark> tags
+	Highlight string
 }
@@ -0,0 +1,30 @@
+package model
+
+import "github.com/google/uuid"
+
+const (
+	// OperationCreate batch operation creating company
+	OperationCreate = "create"
+	// OperationUpdate batch operation updating company
+	OperationUpdate = "update"
+	// OperationDelete batch operation moving company to trash
+	OperationDelete = "delete"
+)
+
+// CompanyOperation company mutation executed as a part of batch, Company is ignored by delete
+type CompanyOperation struct {
+	Action  string
+	ID      uuid.UUID
+	Version int64
+	Force   bool
+	Company *Company
+}
+
+// CompanyOperationResult outcome of batch operation. Executed is false for operations skipped
+// after failure of atomic batch, Err is set for failed operation
+type CompanyOperationResult struct {
+	ID       uuid.UUID
+	Version  int64
+	Executed bool
+	Err      error
+}
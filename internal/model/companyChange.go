@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ChangeUpsert company has been created or changed, change carries its new state
+	ChangeUpsert = "upsert"
+	// ChangeDelete company has been deleted or merged into another one
+	ChangeDelete = "delete"
+)
+
+// CompanyChange entry of companies changes feed, Company is nil for deletions
+type CompanyChange struct {
+	Seq       int64
+	Type      string
+	CompanyID uuid.UUID
+	Company   *Company
+	ChangedAt time.Time
+}
+
+// CompanyChangePage changes following requested cursor in order of publishing.
+// NextCursor resumes the feed after the last returned change
+type CompanyChangePage struct {
+	Items      []*CompanyChange
+	NextCursor string
+	HasMore    bool
+}
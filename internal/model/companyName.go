@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// legalSuffixes legal entity forms ignored when comparing company names
+var legalSuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+	"llc": true, "llp": true, "lp": true, "ltd": true, "limited": true, "plc": true, "pty": true,
+	"gmbh": true, "ag": true, "kg": true, "sa": true, "sas": true, "sarl": true, "srl": true, "spa": true,
+	"bv": true, "nv": true, "oy": true, "ab": true, "as": true, "ooo": true,
+}
+
+// NormalizeCompanyName reduces company name to the form used for duplicate detection:
+// lowercase words without punctuation and trailing legal entity forms
+func NormalizeCompanyName(name string) string {
+	words := strings.FieldsFunc(strings.Map(func(r rune) rune {
+		switch {
+		case r == '.' || r == ',' || r == '\'' || r == '’':
+			return -1
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		default:
+			return ' '
+		}
+	}, name), unicode.IsSpace)
+	for len(words) > 1 && legalSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// CompanyMatch company with similarity of its name to the checked one, from 0 to 1
+type CompanyMatch struct {
+	Company
+	Similarity float64
+}
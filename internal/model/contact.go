@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Contact person working at company
+type Contact struct {
+	ID        uuid.UUID `bson:"_id"`
+	CompanyID uuid.UUID `bson:"company_id"`
+	Name      string    `bson:"name"`
+	Role      string    `bson:"role"`
+	Email     string    `bson:"email"`
+	Phone     string    `bson:"phone"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// ContactPage one page of company contacts ordered by name
+type ContactPage struct {
+	Items      []*Contact
+	NextCursor string
+}
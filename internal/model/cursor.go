@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Cursor keyset pagination position: value of sort column and id of the last returned entry
+type Cursor struct {
+	Value string    `json:"v,omitempty"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Encode returns opaque string representation of cursor
+func (c *Cursor) Encode() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses cursor from its opaque string representation
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	cursor := new(Cursor)
+	err = json.Unmarshal(data, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	return cursor, nil
+}
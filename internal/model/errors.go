@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrVersionConflict stored entity version differs from the expected one
+	ErrVersionConflict = errors.New("entity has been modified concurrently")
+	// ErrForbidden user has no rights for the operation
+	ErrForbidden = errors.New("operation is not permitted")
+	// ErrLastOwner operation would leave company without owners
+	ErrLastOwner = errors.New("company must have at least one owner")
+	// ErrParentNotFound parent company doesn't exist
+	ErrParentNotFound = errors.New("parent company not found")
+	// ErrHierarchyCycle company would become its own ancestor
+	ErrHierarchyCycle = errors.New("company cannot be a subsidiary of itself or of its subsidiaries")
+	// ErrParentCommentNotFound replied comment doesn't exist in company or has been deleted
+	ErrParentCommentNotFound = errors.New("parent comment not found")
+	// ErrChangesExpired changes following cursor are no longer retained
+	ErrChangesExpired = errors.New("changes since cursor are no longer retained, companies must be resynchronized")
+	// ErrUnsupportedMediaType uploaded file isn't an image of supported format
+	ErrUnsupportedMediaType = errors.New("unsupported image format, expected PNG, JPEG, GIF, WebP or SVG")
+	// ErrFileTooLarge uploaded file exceeds allowed size
+	ErrFileTooLarge = errors.New("file exceeds maximum allowed size")
+	// ErrImageTooLarge uploaded image exceeds allowed dimensions
+	ErrImageTooLarge = errors.New("image exceeds maximum allowed dimensions")
+	// ErrLogoExists company already has a logo, it must be replaced instead
+	ErrLogoExists = errors.New("company already has a logo")
+)
+
+// DuplicateError company name is similar to names of existing companies
+type DuplicateError struct {
+	Candidates []*CompanyMatch
+}
+
+func (e *DuplicateError) Error() string {
+	return "company with similar name already exists"
+}
+
+// MovedError requested company has been merged into company with ID
+type MovedError struct {
+	ID uuid.UUID
+}
+
+func (e *MovedError) Error() string {
+	return fmt.Sprintf("company has been merged into %v", e.ID)
+}
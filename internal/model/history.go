@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// HistoryCreate company has been created
+	HistoryCreate = "CREATE"
+	// HistoryUpdate company has been updated
+	HistoryUpdate = "UPDATE"
+	// HistoryDelete company has been moved to trash
+	HistoryDelete = "DELETE"
+	// HistoryRestore company has been restored from trash
+	HistoryRestore = "RESTORE"
+	// HistoryAddLogo logo has been added to company
+	HistoryAddLogo = "ADD_LOGO"
+	// HistoryReplaceLogo new logo version has been uploaded
+	HistoryReplaceLogo = "REPLACE_LOGO"
+	// HistoryDeleteLogo logo has been removed from company, its versions are kept
+	HistoryDeleteLogo = "DELETE_LOGO"
+	// HistoryRevertLogo earlier logo version has been made current
+	HistoryRevertLogo = "REVERT_LOGO"
+	// HistoryMerge company has been merged into another one or another company has been merged into it
+	HistoryMerge = "MERGE"
+)
+
+// CompanyHistory company change record, Before and After are snapshots of changed state
+type CompanyHistory struct {
+	ID        uuid.UUID
+	CompanyID uuid.UUID
+	UserID    uuid.UUID
+	Action    string
+	ChangedAt time.Time
+	Before    map[string]interface{}
+	After     map[string]interface{}
+	Changes   []FieldChange
+}
+
+// FieldChange value of field before and after change, nested fields are separated by dots
+type FieldChange struct {
+	Field  string
+	Before interface{}
+	After  interface{}
+}
+
+// CompanyHistoryPage one page of company history, newest changes first
+type CompanyHistoryPage struct {
+	Items      []*CompanyHistory
+	NextCursor string
+}
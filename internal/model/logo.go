@@ -1,10 +1,33 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
 
-// Logo company logo domain model
+	"github.com/google/uuid"
+)
+
+// Logo version of company logo, only Current version is served. Image is storage key of logo content,
+// CreatedBy is empty for logos uploaded before versioning
 type Logo struct {
-	ID        uuid.UUID
-	CompanyID uuid.UUID
-	Image     string
+	ID          uuid.UUID
+	CompanyID   uuid.UUID
+	Version     int
+	Current     bool
+	Image       string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+	CreatedBy   *uuid.UUID
+	Variants    []*LogoVariant
+}
+
+// LogoVariant downscaled copy of logo fitted into Dimension x Dimension square
+type LogoVariant struct {
+	LogoID      uuid.UUID
+	Dimension   int
+	Width       int
+	Height      int
+	Image       string
+	ContentType string
+	Size        int64
 }
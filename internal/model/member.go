@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// RoleOwner manages company, its members and can delete it
+	RoleOwner = "owner"
+	// RoleEditor modifies company profile and logo
+	RoleEditor = "editor"
+	// RoleViewer reads company, grants no modification rights
+	RoleViewer = "viewer"
+)
+
+// CompanyMember user role in company
+type CompanyMember struct {
+	CompanyID uuid.UUID
+	UserID    uuid.UUID
+	Role      string
+	GrantedBy uuid.UUID
+	GrantedAt time.Time
+}
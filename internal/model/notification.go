@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification change of company watched by user, Event is one of company stream events.
+// Company name is kept as it was at the moment of change
+type Notification struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	CompanyID   uuid.UUID
+	CompanyName string
+	Event       string
+	CreatedAt   time.Time
+	ReadAt      *time.Time
+}
+
+// NotificationPage one page of notifications, newest first
+type NotificationPage struct {
+	Items      []*Notification
+	NextCursor string
+}
@@ -0,0 +1,29 @@
+package model
+
+import "strings"
+
+// Tag company label with count of not deleted companies labeled by it
+type Tag struct {
+	Name      string
+	Companies int64
+}
+
+// NormalizeTag lowercases tag name and collapses whitespace
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// NormalizeTags normalizes tag names and drops empty and repeated ones
+func NormalizeTags(names []string) []string {
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag := NormalizeTag(name)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -3,14 +3,16 @@ package producer
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/go-redis/redis/v9"
-	"github.com/google/uuid"
+
+	"entetry/gotest/internal/model"
 )
 
 // Company producer company interface
 type Company interface {
-	Produce(ctx context.Context, id uuid.UUID, event, name string) error
+	Produce(ctx context.Context, event string, company *model.Company) error
 }
 
 type redisCompany struct {
@@ -25,13 +27,18 @@ func NewRedisCompanyProducer(redisClient *redis.Client) Company {
 }
 
 // Produce Push new company record into redis stream
-func (r *redisCompany) Produce(ctx context.Context, id uuid.UUID, event, name string) error {
+func (r *redisCompany) Produce(ctx context.Context, event string, company *model.Company) error {
+	payload, err := json.Marshal(company)
+	if err != nil {
+		return err
+	}
 	args := &redis.XAddArgs{
 		Stream: "company",
 		Values: map[string]interface{}{
-			"id":    id.String(),
-			"event": event,
-			"name":  name,
+			"id":      company.ID.String(),
+			"event":   event,
+			"name":    company.Name,
+			"company": string(payload),
 		},
 	}
 	return r.redis.XAdd(ctx, args).Err()
@@ -0,0 +1,152 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"entetry/gotest/internal/model"
+)
+
+// Comment company comments mongo repository struct, replies are counted on the parent document
+type Comment struct {
+	db *mongo.Collection
+}
+
+// NewCommentRepository creates new company comments repository object
+func NewCommentRepository(db *mongo.Database) *Comment {
+	return &Comment{db: db.Collection("company_comment")}
+}
+
+// CreateIndexes creates indexes required by comment queries
+func (c *Comment) CreateIndexes(ctx context.Context) error {
+	_, err := c.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "company_id", Value: 1}, {Key: "parent_id", Value: 1},
+			{Key: "created_at", Value: 1}, {Key: "_id", Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create comment indexes: %v", err)
+	}
+	return nil
+}
+
+// Create inserts comment in db
+func (c *Comment) Create(ctx context.Context, comment *model.Comment) error {
+	comment.ID = uuid.New()
+	comment.CreatedAt = time.Now().UTC()
+	if comment.Mentions == nil {
+		comment.Mentions = []uuid.UUID{}
+	}
+	_, err := c.db.InsertOne(ctx, comment)
+	if err != nil {
+		return fmt.Errorf("cannot create comment: %v", err)
+	}
+	if comment.ParentID != nil {
+		_, err = c.db.UpdateOne(ctx, bson.M{"_id": *comment.ParentID}, bson.M{"$inc": bson.M{"replies": 1}})
+		if err != nil {
+			return fmt.Errorf("cannot count reply: %v", err)
+		}
+	}
+	return nil
+}
+
+// Update changes body and mentions of not deleted comment and refreshes comment with the stored state
+func (c *Comment) Update(ctx context.Context, comment *model.Comment) error {
+	mentions := comment.Mentions
+	if mentions == nil {
+		mentions = []uuid.UUID{}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"body":      comment.Body,
+			"mentions":  mentions,
+			"edited_at": time.Now().UTC(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := c.db.FindOneAndUpdate(ctx, bson.M{"_id": comment.ID, "company_id": comment.CompanyID, "deleted_at": nil},
+		update, opts).Decode(comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return echo.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update comment: %v", err)
+	}
+	return nil
+}
+
+// Delete replaces comment by a tombstone keeping its replies
+func (c *Comment) Delete(ctx context.Context, companyID, id uuid.UUID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"body":       "",
+			"mentions":   []uuid.UUID{},
+			"deleted_at": time.Now().UTC(),
+		},
+	}
+	result, err := c.db.UpdateOne(ctx, bson.M{"_id": id, "company_id": companyID, "deleted_at": nil}, update)
+	if err != nil {
+		return fmt.Errorf("cannot delete comment: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// GetOne gets comment of company by its id
+func (c *Comment) GetOne(ctx context.Context, companyID, id uuid.UUID) (*model.Comment, error) {
+	comment := new(model.Comment)
+	err := c.db.FindOne(ctx, bson.M{"_id": id, "company_id": companyID}).Decode(comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get comment: %v", err)
+	}
+	return comment, nil
+}
+
+// GetByParent returns page of replies to comment or of top level comments if parentID is nil, oldest first
+func (c *Comment) GetByParent(ctx context.Context, companyID uuid.UUID, parentID *uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CommentPage, error) {
+	query := bson.M{"company_id": companyID, "parent_id": parentID}
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		query["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$gt": createdAt}},
+			bson.M{"created_at": createdAt, "_id": bson.M{"$gt": cursor.ID}},
+		}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit + 1))
+	result, err := c.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := new(model.CommentPage)
+	if err = result.All(ctx, &page.Items); err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+	return page, nil
+}
@@ -5,15 +5,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"entetry/gotest/internal/model"
 )
 
+// searchDocument company document with text search score
+type searchDocument struct {
+	model.Company `bson:",inline"`
+	Score         float64 `bson:"score"`
+}
+
 // Company mongo company repository struct
 type Company struct {
 	db *mongo.Collection
@@ -26,33 +39,140 @@ func NewCompanyRepository(db *mongo.Database) *Company {
 	}
 }
 
-// GetAll get all companies from db
-func (c *Company) GetAll(ctx context.Context) ([]*model.Company, error) {
-	cursor, err := c.db.Find(ctx, bson.M{})
+// GetAll get page of companies from db
+func (c *Company) GetAll(ctx context.Context, filter *model.CompanyFilter) (*model.CompanyPage, error) {
+	query := companyFilter(filter)
+
+	page := new(model.CompanyPage)
+	total, err := c.db.CountDocuments(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	page.Total = total
+
+	field, direction, comparison := companySorting(filter)
+	if filter.Cursor != nil {
+		var keyset bson.M
+		if field == "_id" {
+			keyset = bson.M{"_id": bson.M{comparison: filter.Cursor.ID}}
+		} else {
+			keyset = bson.M{"$or": bson.A{
+				bson.M{field: bson.M{comparison: filter.Cursor.Value}},
+				bson.M{field: filter.Cursor.Value, "_id": bson.M{comparison: filter.Cursor.ID}},
+			}}
+		}
+		query = bson.M{"$and": bson.A{query, keyset}}
+	}
+	opts := options.Find().SetSort(companySort(field, direction)).SetLimit(int64(filter.Limit + 1))
 
-	var result []*model.Company
+	cursor, err := c.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	for cursor.Next(ctx) {
 		company := new(model.Company)
 		if decodeErr := cursor.Decode(company); decodeErr != nil {
 			return nil, decodeErr
 		}
-		result = append(result, company)
+		page.Items = append(page.Items, company)
 	}
 	err = cursor.Close(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return result, nil
+
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{ID: last.ID}
+		if field == "name" {
+			next.Value = last.Name
+		}
+		page.NextCursor = next.Encode()
+	}
+	return page, nil
+}
+
+// Export streams all companies matching filter to fn in listing order, filter cursor and limit are ignored
+func (c *Company) Export(ctx context.Context, filter *model.CompanyFilter, fn func(company *model.Company) error) error {
+	field, direction, _ := companySorting(filter)
+	cursor, err := c.db.Find(ctx, companyFilter(filter), options.Find().SetSort(companySort(field, direction)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := cursor.Close(ctx); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}()
+
+	for cursor.Next(ctx) {
+		company := new(model.Company)
+		if err = cursor.Decode(company); err != nil {
+			return err
+		}
+		if err = fn(company); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CreateIndexes creates indexes required by company queries
+func (c *Company) CreateIndexes(ctx context.Context) error {
+	_, err := c.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}},
+			Options: options.Index().SetDefaultLanguage("none"),
+		},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create company indexes: %v", err)
+	}
+	return nil
+}
+
+// Search finds companies by text index, most relevant first
+func (c *Company) Search(ctx context.Context, query string, limit int) ([]*model.CompanySearchResult, error) {
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "name", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := c.db.Find(ctx, bson.M{"$text": bson.M{"$search": query}, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*model.CompanySearchResult
+	terms := strings.Fields(query)
+
+	for cursor.Next(ctx) {
+		document := new(searchDocument)
+		if decodeErr := cursor.Decode(document); decodeErr != nil {
+			return nil, decodeErr
+		}
+		results = append(results, &model.CompanySearchResult{
+			Company:   document.Company,
+			Rank:      document.Score,
+			Highlight: highlight(document.Name, terms),
+		})
+	}
+	err = cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
 // GetOne get Company by its uuid
 func (c *Company) GetOne(ctx context.Context, id uuid.UUID) (*model.Company, error) {
 	company := &model.Company{}
-	err := c.db.FindOne(ctx, bson.M{"_id": id}).Decode(company)
+	err := c.db.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(company)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, echo.ErrNotFound
 	} else if err != nil {
@@ -61,9 +181,18 @@ func (c *Company) GetOne(ctx context.Context, id uuid.UUID) (*model.Company, err
 	return company, nil
 }
 
+// GetForUpdate get Company by its uuid, mongo documents can't be locked, so concurrent changes
+// are only detected by version checks of updates
+func (c *Company) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Company, error) {
+	return c.GetOne(ctx, id)
+}
+
 // Create creates New Company record in db
 func (c *Company) Create(ctx context.Context, company *model.Company) (uuid.UUID, error) {
 	company.ID = uuid.New()
+	company.Version = 1
+	company.CreatedAt = time.Now().UTC()
+	company.UpdatedAt = company.CreatedAt
 	_, err := c.db.InsertOne(ctx, company)
 	if err != nil {
 		return company.ID, fmt.Errorf("cannot create Company: %v", err)
@@ -71,23 +200,218 @@ func (c *Company) Create(ctx context.Context, company *model.Company) (uuid.UUID
 	return company.ID, err
 }
 
-// Update updates company in db
+// CreateBatch inserts new companies into db assigning their ids and creation time
+func (c *Company) CreateBatch(ctx context.Context, companies []*model.Company) error {
+	now := time.Now().UTC()
+	documents := make([]interface{}, len(companies))
+	for i, company := range companies {
+		company.ID = uuid.New()
+		company.Version = 1
+		company.CreatedAt = now
+		company.UpdatedAt = now
+		documents[i] = company
+	}
+	_, err := c.db.InsertMany(ctx, documents)
+	if err != nil {
+		return fmt.Errorf("cannot insert companies: %v", err)
+	}
+	return nil
+}
+
+// Update updates company in db if its version equals company.Version (any version if it is 0)
+// and refreshes company with the stored state
 func (c *Company) Update(ctx context.Context, company *model.Company) error {
-	r, err := c.db.UpdateOne(ctx, bson.M{"_id": company.ID}, bson.M{"$set": bson.M{"name": company.Name}})
+	update := bson.M{
+		"$set": bson.M{
+			"name":            company.Name,
+			"description":     company.Description,
+			"website":         company.Website,
+			"industry":        company.Industry,
+			"founded_year":    company.FoundedYear,
+			"headcount":       company.Headcount,
+			"address":         company.Address,
+			"parent_id":       company.ParentID,
+			"normalized_name": company.NormalizedName,
+		},
+		"$inc":         bson.M{"version": 1},
+		"$currentDate": bson.M{"updated_at": true},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := c.db.FindOneAndUpdate(ctx, versionFilter(company.ID, company.Version), update, opts).Decode(company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.notModifiedError(ctx, company.ID)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot update Company: %v", err)
 	}
+	return nil
+}
+
+// Delete marks company as deleted if its version equals given one (any version if it is 0)
+func (c *Company) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	r, err := c.db.UpdateOne(ctx, versionFilter(id, version), bson.M{
+		"$set":         bson.M{"deleted_at": time.Now()},
+		"$inc":         bson.M{"version": 1},
+		"$currentDate": bson.M{"updated_at": true},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete Company: %v", err)
+	}
 	if r.MatchedCount == 0 {
-		return echo.ErrNotFound
+		return c.notModifiedError(ctx, id)
 	}
 	return nil
 }
 
-// Delete delete company from db
-func (c *Company) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := c.db.DeleteOne(ctx, bson.M{"_id": id})
+// Restore brings back deleted company
+func (c *Company) Restore(ctx context.Context, id uuid.UUID) error {
+	r, err := c.db.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}, bson.M{
+		"$unset":       bson.M{"deleted_at": ""},
+		"$inc":         bson.M{"version": 1},
+		"$currentDate": bson.M{"updated_at": true},
+	})
 	if err != nil {
-		return fmt.Errorf("cannot delete Company: %v", err)
+		return fmt.Errorf("cannot restore Company: %v", err)
+	}
+	if r.MatchedCount == 0 {
+		return echo.ErrNotFound
 	}
 	return nil
 }
+
+// Purge permanently removes companies deleted before given time and returns their ids
+func (c *Company) Purge(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	filter := bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}
+	cursor, err := c.db.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+
+	for cursor.Next(ctx) {
+		company := new(model.Company)
+		if decodeErr := cursor.Decode(company); decodeErr != nil {
+			return nil, decodeErr
+		}
+		ids = append(ids, company.ID)
+	}
+	err = cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	_, err = c.db.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": filter["deleted_at"]})
+	if err != nil {
+		return nil, fmt.Errorf("cannot purge companies: %v", err)
+	}
+	_, err = c.db.UpdateMany(ctx, bson.M{"parent_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"parent_id": nil}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot detach subsidiaries of purged companies: %v", err)
+	}
+	_, err = c.db.Database().Collection("company_alias").DeleteMany(ctx, bson.M{"company_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot delete aliases of purged companies: %v", err)
+	}
+	_, err = c.db.Database().Collection("contact").DeleteMany(ctx, bson.M{"company_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot delete contacts of purged companies: %v", err)
+	}
+	_, err = c.db.Database().Collection("company_comment").DeleteMany(ctx, bson.M{"company_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot delete comments of purged companies: %v", err)
+	}
+	return ids, nil
+}
+
+// notModifiedError explains why conditional modification of company has not matched any document
+func (c *Company) notModifiedError(ctx context.Context, id uuid.UUID) error {
+	count, err := c.db.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil})
+	if err != nil {
+		return fmt.Errorf("cannot check Company existence: %v", err)
+	}
+	if count > 0 {
+		return model.ErrVersionConflict
+	}
+	return echo.ErrNotFound
+}
+
+// versionFilter matches not deleted company with given version (any version if it is 0)
+func versionFilter(id uuid.UUID, version int64) bson.M {
+	filter := bson.M{"_id": id, "deleted_at": nil}
+	if version != 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+func companyFilter(filter *model.CompanyFilter) bson.M {
+	query := bson.M{"deleted_at": nil}
+	if filter.Deleted {
+		query["deleted_at"] = bson.M{"$ne": nil}
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+	if filter.UpdatedSince != nil {
+		query["updated_at"] = bson.M{"$gte": *filter.UpdatedSince}
+	}
+	if filter.CreatedBefore != nil {
+		query["created_at"] = bson.M{"$lt": *filter.CreatedBefore}
+	}
+	if filter.Name == "" {
+		return query
+	}
+	pattern := regexp.QuoteMeta(filter.Name)
+	if filter.Match != model.MatchContains {
+		pattern = "^" + pattern
+	}
+	query["name"] = primitive.Regex{Pattern: pattern, Options: "i"}
+	return query
+}
+
+// companySort returns sort document with _id as a tiebreaker
+func companySort(field string, direction int) bson.D {
+	if field == "_id" {
+		return bson.D{{Key: "_id", Value: direction}}
+	}
+	return bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+}
+
+// companySorting returns sort field, sort direction and keyset comparison operator
+func companySorting(filter *model.CompanyFilter) (field string, direction int, comparison string) {
+	field = "name"
+	if filter.SortBy == model.SortByID {
+		field = "_id"
+	}
+	if filter.Desc {
+		return field, -1, "$lt"
+	}
+	return field, 1, "$gt"
+}
+
+// highlight escapes text and wraps occurrences of search terms into <mark> tags
+func highlight(text string, terms []string) string {
+	if len(terms) == 0 {
+		return html.EscapeString(text)
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	var highlighted strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		highlighted.WriteString(html.EscapeString(text[last:match[0]]))
+		highlighted.WriteString("<mark>")
+		highlighted.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		highlighted.WriteString("</mark>")
+		last = match[1]
+	}
+	highlighted.WriteString(html.EscapeString(text[last:]))
+	return highlighted.String()
+}
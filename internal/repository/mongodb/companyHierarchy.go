@@ -0,0 +1,88 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"entetry/gotest/internal/model"
+)
+
+// GetAncestors returns chain of not deleted parents of company, nearest parent first
+func (c *Company) GetAncestors(ctx context.Context, id uuid.UUID) ([]*model.Company, error) {
+	return c.graphLookup(ctx, id, bson.M{
+		"startWith":        "$parent_id",
+		"connectFromField": "parent_id",
+		"connectToField":   "_id",
+		"maxDepth":         model.MaxHierarchyDepth - 1,
+	})
+}
+
+// IsAncestor reports whether company ancestorID is in parents chain of company id. Deleted parents are walked
+// through as well because they can be restored
+func (c *Company) IsAncestor(ctx context.Context, id, ancestorID uuid.UUID) (bool, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"_id": id}},
+		bson.M{"$graphLookup": bson.M{
+			"from":             c.db.Name(),
+			"startWith":        "$parent_id",
+			"connectFromField": "parent_id",
+			"connectToField":   "_id",
+			"as":               "ancestors",
+			"maxDepth":         model.MaxHierarchyDepth - 1,
+		}},
+		bson.M{"$match": bson.M{"ancestors._id": ancestorID}},
+		bson.M{"$project": bson.M{"_id": 1}},
+	}
+	cursor, err := c.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return false, err
+	}
+
+	var matched []bson.M
+	if err = cursor.All(ctx, &matched); err != nil {
+		return false, err
+	}
+	return len(matched) > 0, nil
+}
+
+// LockHierarchy does nothing, mongo repository doesn't take part in transactions
+func (c *Company) LockHierarchy(_ context.Context) error {
+	return nil
+}
+
+// GetSubsidiaries returns not deleted subsidiaries of company up to given depth, ordered by depth and name
+func (c *Company) GetSubsidiaries(ctx context.Context, id uuid.UUID, depth int) ([]*model.Company, error) {
+	return c.graphLookup(ctx, id, bson.M{
+		"startWith":        "$_id",
+		"connectFromField": "_id",
+		"connectToField":   "parent_id",
+		"maxDepth":         depth - 1,
+	})
+}
+
+// graphLookup returns companies related to company by $graphLookup stage with given traversal options
+func (c *Company) graphLookup(ctx context.Context, id uuid.UUID, traversal bson.M) ([]*model.Company, error) {
+	traversal["from"] = c.db.Name()
+	traversal["as"] = "related"
+	traversal["depthField"] = "depth"
+	traversal["restrictSearchWithMatch"] = bson.M{"deleted_at": nil}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"_id": id}},
+		bson.M{"$graphLookup": traversal},
+		bson.M{"$unwind": "$related"},
+		bson.M{"$replaceRoot": bson.M{"newRoot": "$related"}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "depth", Value: 1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := c.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var companies []*model.Company
+	if err = cursor.All(ctx, &companies); err != nil {
+		return nil, err
+	}
+	return companies, nil
+}
@@ -0,0 +1,98 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"entetry/gotest/internal/model"
+)
+
+// aliasDocument id of merged company pointing to the company it has been merged into
+type aliasDocument struct {
+	AliasID   uuid.UUID `bson:"_id"`
+	CompanyID uuid.UUID `bson:"company_id"`
+}
+
+// Merge moves tags, contacts, comments, subsidiaries and aliases of not deleted source company to not deleted target,
+// removes source and keeps its id as an alias of target. Logos and members aren't stored in mongo
+func (c *Company) Merge(ctx context.Context, sourceID, targetID uuid.UUID) (*model.CompanyMerge, error) {
+	source, err := c.GetOne(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = c.GetOne(ctx, targetID); err != nil {
+		return nil, err
+	}
+
+	merge := new(model.CompanyMerge)
+	cursor, err := c.db.Find(ctx, bson.M{"parent_id": sourceID})
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &merge.Subsidiaries); err != nil {
+		return nil, err
+	}
+	_, err = c.db.UpdateMany(ctx, bson.M{"parent_id": sourceID}, bson.M{
+		"$set":         bson.M{"parent_id": targetID},
+		"$inc":         bson.M{"version": 1},
+		"$currentDate": bson.M{"updated_at": true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot move subsidiaries: %v", err)
+	}
+	for _, subsidiary := range merge.Subsidiaries {
+		subsidiary.ParentID = &targetID
+		subsidiary.Version++
+	}
+
+	update := bson.M{"$inc": bson.M{"version": 1}, "$currentDate": bson.M{"updated_at": true}}
+	if len(source.Tags) > 0 {
+		update["$addToSet"] = bson.M{"tags": bson.M{"$each": source.Tags}}
+	}
+	if _, err = c.db.UpdateOne(ctx, bson.M{"_id": targetID}, update); err != nil {
+		return nil, fmt.Errorf("cannot merge company tags: %v", err)
+	}
+
+	_, err = c.db.Database().Collection("contact").UpdateMany(ctx, bson.M{"company_id": sourceID},
+		bson.M{"$set": bson.M{"company_id": targetID}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot move company contacts: %v", err)
+	}
+	_, err = c.db.Database().Collection("company_comment").UpdateMany(ctx, bson.M{"company_id": sourceID},
+		bson.M{"$set": bson.M{"company_id": targetID}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot move company comments: %v", err)
+	}
+
+	aliases := c.db.Database().Collection("company_alias")
+	_, err = aliases.UpdateMany(ctx, bson.M{"company_id": sourceID}, bson.M{"$set": bson.M{"company_id": targetID}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot move company aliases: %v", err)
+	}
+	if _, err = aliases.InsertOne(ctx, &aliasDocument{AliasID: sourceID, CompanyID: targetID}); err != nil {
+		return nil, fmt.Errorf("cannot create company alias: %v", err)
+	}
+	if _, err = c.db.DeleteOne(ctx, bson.M{"_id": sourceID}); err != nil {
+		return nil, fmt.Errorf("cannot delete merged Company: %v", err)
+	}
+	return merge, nil
+}
+
+// ResolveAlias returns id of company which company with given id has been merged into
+func (c *Company) ResolveAlias(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	var alias aliasDocument
+	err := c.db.Database().Collection("company_alias").FindOne(ctx, bson.M{"_id": id}).Decode(&alias)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return uuid.Nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("cannot resolve company alias: %v", err)
+	}
+	return alias.CompanyID, nil
+}
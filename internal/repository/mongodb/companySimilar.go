@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"entetry/gotest/internal/model"
+)
+
+// maxSimilarCandidates limit of companies sharing a word with checked name compared by similarity
+const maxSimilarCandidates = 1000
+
+// FindSimilar returns not deleted companies which normalized names have trigram similarity to the given one
+// at least threshold, most similar first. Only companies sharing at least one word with the name are compared
+func (c *Company) FindSimilar(ctx context.Context, normalizedName string, excludeID uuid.UUID, threshold float64,
+	limit int) ([]*model.CompanyMatch, error) {
+	words := strings.Fields(normalizedName)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	filter := bson.M{
+		"deleted_at":      nil,
+		"_id":             bson.M{"$ne": excludeID},
+		"normalized_name": primitive.Regex{Pattern: `\b(` + strings.Join(words, "|") + `)\b`},
+	}
+	cursor, err := c.db.Find(ctx, filter, options.Find().SetLimit(maxSimilarCandidates))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*model.Company
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	nameTrigrams := trigrams(normalizedName)
+	var matches []*model.CompanyMatch
+	for _, candidate := range candidates {
+		similarity := trigramSimilarity(nameTrigrams, trigrams(candidate.NormalizedName))
+		if similarity >= threshold {
+			matches = append(matches, &model.CompanyMatch{Company: *candidate, Similarity: similarity})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// trigrams returns set of trigrams of words padded like pg_trgm does
+func trigrams(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(text) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// trigramSimilarity returns count of shared trigrams divided by count of all distinct trigrams
+func trigramSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for trigram := range a {
+		if b[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
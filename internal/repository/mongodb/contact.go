@@ -0,0 +1,126 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"entetry/gotest/internal/model"
+)
+
+// Contact company contacts mongo repository struct
+type Contact struct {
+	db *mongo.Collection
+}
+
+// NewContactRepository creates new company contacts repository object
+func NewContactRepository(db *mongo.Database) *Contact {
+	return &Contact{db: db.Collection("contact")}
+}
+
+// CreateIndexes creates indexes required by contact queries
+func (c *Contact) CreateIndexes(ctx context.Context) error {
+	_, err := c.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "company_id", Value: 1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create contact indexes: %v", err)
+	}
+	return nil
+}
+
+// Create inserts contact in db
+func (c *Contact) Create(ctx context.Context, contact *model.Contact) error {
+	contact.ID = uuid.New()
+	contact.CreatedAt = time.Now().UTC()
+	_, err := c.db.InsertOne(ctx, contact)
+	if err != nil {
+		return fmt.Errorf("cannot create contact: %v", err)
+	}
+	return nil
+}
+
+// Update updates contact of company and refreshes contact with the stored state
+func (c *Contact) Update(ctx context.Context, contact *model.Contact) error {
+	update := bson.M{
+		"$set": bson.M{
+			"name":  contact.Name,
+			"role":  contact.Role,
+			"email": contact.Email,
+			"phone": contact.Phone,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := c.db.FindOneAndUpdate(ctx, bson.M{"_id": contact.ID, "company_id": contact.CompanyID}, update, opts).
+		Decode(contact)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return echo.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update contact: %v", err)
+	}
+	return nil
+}
+
+// Delete deletes contact of company
+func (c *Contact) Delete(ctx context.Context, companyID, id uuid.UUID) error {
+	result, err := c.db.DeleteOne(ctx, bson.M{"_id": id, "company_id": companyID})
+	if err != nil {
+		return fmt.Errorf("cannot delete contact: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// GetOne gets contact of company by its id
+func (c *Contact) GetOne(ctx context.Context, companyID, id uuid.UUID) (*model.Contact, error) {
+	contact := new(model.Contact)
+	err := c.db.FindOne(ctx, bson.M{"_id": id, "company_id": companyID}).Decode(contact)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get contact: %v", err)
+	}
+	return contact, nil
+}
+
+// GetByCompanyID returns page of company contacts ordered by name
+func (c *Contact) GetByCompanyID(ctx context.Context, companyID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.ContactPage, error) {
+	query := bson.M{"company_id": companyID}
+	if cursor != nil {
+		query["$or"] = bson.A{
+			bson.M{"name": bson.M{"$gt": cursor.Value}},
+			bson.M{"name": cursor.Value, "_id": bson.M{"$gt": cursor.ID}},
+		}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit + 1))
+	result, err := c.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := new(model.ContactPage)
+	if err = result.All(ctx, &page.Items); err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.Name, ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+	return page, nil
+}
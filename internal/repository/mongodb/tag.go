@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"entetry/gotest/internal/model"
+)
+
+// Tag company tags mongo repository struct, tags are stored in company documents
+type Tag struct {
+	db *mongo.Collection
+}
+
+// tagDocument tag usage aggregated from company documents
+type tagDocument struct {
+	Name      string `bson:"_id"`
+	Companies int64  `bson:"companies"`
+}
+
+// NewTagRepository creates new company tags repository object
+func NewTagRepository(db *mongo.Database) *Tag {
+	return &Tag{db: db.Collection("company")}
+}
+
+// AddToCompany labels not deleted company by tags, company version is incremented
+func (t *Tag) AddToCompany(ctx context.Context, companyID uuid.UUID, names []string) error {
+	result, err := t.db.UpdateOne(ctx, versionFilter(companyID, 0), bson.M{
+		"$addToSet":    bson.M{"tags": bson.M{"$each": names}},
+		"$inc":         bson.M{"version": 1},
+		"$currentDate": bson.M{"updated_at": true},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot add company tags: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return echo.ErrNotFound
+	}
+	// $addToSet can't sort, so tags are sorted by pushing nothing
+	_, err = t.db.UpdateOne(ctx, bson.M{"_id": companyID}, bson.M{
+		"$push": bson.M{"tags": bson.M{"$each": bson.A{}, "$sort": 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot sort company tags: %v", err)
+	}
+	return nil
+}
+
+// RemoveFromCompany removes tag from not deleted company, company version is incremented
+func (t *Tag) RemoveFromCompany(ctx context.Context, companyID uuid.UUID, name string) error {
+	filter := versionFilter(companyID, 0)
+	filter["tags"] = name
+	result, err := t.db.UpdateOne(ctx, filter, bson.M{
+		"$pull":        bson.M{"tags": name},
+		"$inc":         bson.M{"version": 1},
+		"$currentDate": bson.M{"updated_at": true},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot remove company tag: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// Autocomplete returns tags starting with prefix, most used first
+func (t *Tag) Autocomplete(ctx context.Context, prefix string, limit int) ([]*model.Tag, error) {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"deleted_at": nil, "tags": pattern}},
+		bson.M{"$unwind": "$tags"},
+		bson.M{"$match": bson.M{"tags": pattern}},
+		bson.M{"$group": bson.M{"_id": "$tags", "companies": bson.M{"$sum": 1}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "companies", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.M{"$limit": limit},
+	}
+	cursor, err := t.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []*tagDocument
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+	tags := make([]*model.Tag, len(documents))
+	for i, document := range documents {
+		tags[i] = &model.Tag{Name: document.Name, Companies: document.Companies}
+	}
+	return tags, nil
+}
@@ -0,0 +1,173 @@
+package postgre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// CommentRepository company comments repository interface
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	Update(ctx context.Context, comment *model.Comment) error
+	Delete(ctx context.Context, companyID, id uuid.UUID) error
+	GetOne(ctx context.Context, companyID, id uuid.UUID) (*model.Comment, error)
+	GetByParent(ctx context.Context, companyID uuid.UUID, parentID *uuid.UUID, cursor *model.Cursor,
+		limit int) (*model.CommentPage, error)
+}
+
+// mentions are converted to text[] because pgtype treats uuid.UUID as a nested array
+const commentColumns = `id, company_id, parent_id, author_id, body, mentions::text[],
+	(SELECT count(1) FROM company_comment reply WHERE reply.parent_id = company_comment.id) AS replies,
+	created_at, edited_at, deleted_at`
+
+// Comment company comments postgres repository struct
+type Comment struct {
+	db *pgxpool.Pool
+}
+
+// NewCommentRepository creates new company comments repository object
+func NewCommentRepository(db *pgxpool.Pool) *Comment {
+	return &Comment{db: db}
+}
+
+// Create inserts comment in db
+func (c *Comment) Create(ctx context.Context, comment *model.Comment) error {
+	comment.ID = uuid.New()
+	err := c.db.QueryRow(ctx, `INSERT INTO company_comment (id, company_id, parent_id, author_id, body, mentions)
+		VALUES ($1, $2, $3, $4, $5, $6::uuid[]) RETURNING created_at`,
+		comment.ID, comment.CompanyID, comment.ParentID, comment.AuthorID, comment.Body, uuidStrings(comment.Mentions)).
+		Scan(&comment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot create comment: %v", err)
+	}
+	return nil
+}
+
+// Update changes body and mentions of not deleted comment and refreshes comment with the stored state
+func (c *Comment) Update(ctx context.Context, comment *model.Comment) error {
+	err := scanComment(c.db.QueryRow(ctx, `UPDATE company_comment SET body = $3, mentions = $4::uuid[], edited_at = now()
+		WHERE company_id = $1 AND id = $2 AND deleted_at IS NULL RETURNING `+commentColumns,
+		comment.CompanyID, comment.ID, comment.Body, uuidStrings(comment.Mentions)), comment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return echo.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update comment: %v", err)
+	}
+	return nil
+}
+
+// Delete replaces comment by a tombstone keeping its replies
+func (c *Comment) Delete(ctx context.Context, companyID, id uuid.UUID) error {
+	tag, err := c.db.Exec(ctx, `UPDATE company_comment SET body = '', mentions = '{}', deleted_at = now()
+		WHERE company_id = $1 AND id = $2 AND deleted_at IS NULL`, companyID, id)
+	if err != nil {
+		return fmt.Errorf("cannot delete comment: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// GetOne gets comment of company by its id
+func (c *Comment) GetOne(ctx context.Context, companyID, id uuid.UUID) (*model.Comment, error) {
+	var comment model.Comment
+	err := scanComment(c.db.QueryRow(ctx, "SELECT "+commentColumns+" FROM company_comment WHERE company_id = $1 AND id = $2",
+		companyID, id), &comment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get comment: %v", err)
+	}
+	return &comment, nil
+}
+
+// GetByParent returns page of replies to comment or of top level comments if parentID is nil, oldest first
+func (c *Comment) GetByParent(ctx context.Context, companyID uuid.UUID, parentID *uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CommentPage, error) {
+	builder := new(queryBuilder)
+	builder.where("company_id = " + builder.arg(companyID))
+	if parentID == nil {
+		builder.where("parent_id IS NULL")
+	} else {
+		builder.where("parent_id = " + builder.arg(*parentID))
+	}
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		builder.where(fmt.Sprintf("(created_at, id) > (%s, %s)", builder.arg(createdAt), builder.arg(cursor.ID)))
+	}
+	query := fmt.Sprintf("SELECT %s FROM company_comment%s ORDER BY created_at, id LIMIT %s",
+		commentColumns, builder.whereClause(), builder.arg(limit+1))
+
+	rows, err := c.db.Query(ctx, query, builder.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	page := new(model.CommentPage)
+
+	for rows.Next() {
+		var comment model.Comment
+
+		err = scanComment(rows, &comment)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		page.Items = append(page.Items, &comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+
+	return page, nil
+}
+
+// scanComment scans row of commentColumns into comment
+func scanComment(row pgx.Row, comment *model.Comment) error {
+	var mentions []string
+	err := row.Scan(&comment.ID, &comment.CompanyID, &comment.ParentID, &comment.AuthorID, &comment.Body, &mentions,
+		&comment.Replies, &comment.CreatedAt, &comment.EditedAt, &comment.DeletedAt)
+	if err != nil {
+		return err
+	}
+	comment.Mentions = make([]uuid.UUID, len(mentions))
+	for i, mention := range mentions {
+		comment.Mentions[i], err = uuid.Parse(mention)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uuidStrings converts ids to not null text array parameter
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
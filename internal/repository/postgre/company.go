@@ -3,10 +3,17 @@ package postgre
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
 
 	"entetry/gotest/internal/model"
 )
@@ -14,12 +21,32 @@ import (
 // CompanyRepository interface for company repository
 type CompanyRepository interface {
 	Create(ctx context.Context, company *model.Company) (uuid.UUID, error)
+	CreateBatch(ctx context.Context, companies []*model.Company) error
 	Update(ctx context.Context, company *model.Company) error
-	Delete(ctx context.Context, uuid uuid.UUID) error
+	Delete(ctx context.Context, uuid uuid.UUID, version int64) error
 	GetOne(ctx context.Context, uuid uuid.UUID) (*model.Company, error)
-	GetAll(ctx context.Context) ([]*model.Company, error)
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Company, error)
+	GetAll(ctx context.Context, filter *model.CompanyFilter) (*model.CompanyPage, error)
+	Export(ctx context.Context, filter *model.CompanyFilter, fn func(company *model.Company) error) error
+	Search(ctx context.Context, query string, limit int) ([]*model.CompanySearchResult, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+	GetAncestors(ctx context.Context, id uuid.UUID) ([]*model.Company, error)
+	IsAncestor(ctx context.Context, id, ancestorID uuid.UUID) (bool, error)
+	LockHierarchy(ctx context.Context) error
+	GetSubsidiaries(ctx context.Context, id uuid.UUID, depth int) ([]*model.Company, error)
+	Merge(ctx context.Context, sourceID, targetID uuid.UUID) (*model.CompanyMerge, error)
+	ResolveAlias(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	FindSimilar(ctx context.Context, normalizedName string, excludeID uuid.UUID, threshold float64,
+		limit int) ([]*model.CompanyMatch, error)
 }
 
+const companyColumns = `id, name, description, website, industry, founded_year, headcount,
+	address_street, address_city, address_region, address_postal_code, address_country, deleted_at, version, created_by, parent_id,
+	created_at, updated_at,
+	ARRAY(SELECT tag.name FROM company_tag JOIN tag ON tag.id = company_tag.tag_id
+		WHERE company_tag.company_id = company.id ORDER BY tag.name) AS tags`
+
 // Company postgres company repository struct
 type Company struct {
 	db *pgxpool.Pool
@@ -32,25 +59,138 @@ func NewCompanyRepository(db *pgxpool.Pool) *Company {
 	}
 }
 
-// GetAll get all companies from db
-func (c *Company) GetAll(ctx context.Context) ([]*model.Company, error) {
-	rows, err := c.db.Query(ctx, `SELECT id, name FROM company`)
+// GetAll get page of companies from db
+func (c *Company) GetAll(ctx context.Context, filter *model.CompanyFilter) (*model.CompanyPage, error) {
+	builder := new(queryBuilder)
+	applyCompanyFilter(builder, filter)
+
+	page := new(model.CompanyPage)
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT count(1) FROM company"+builder.whereClause(), builder.args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count: %v", err)
+	}
+
+	column, direction, comparison := companySorting(filter)
+	if filter.Cursor != nil {
+		if column == "id" {
+			builder.where(fmt.Sprintf("id %s %s", comparison, builder.arg(filter.Cursor.ID)))
+		} else {
+			builder.where(fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparison,
+				builder.arg(filter.Cursor.Value), builder.arg(filter.Cursor.ID)))
+		}
+	}
+	query := fmt.Sprintf("SELECT %s FROM company%s ORDER BY %s LIMIT %s",
+		companyColumns, builder.whereClause(), companyOrderBy(column, direction), builder.arg(filter.Limit+1))
+
+	rows, err := conn(ctx, c.db).Query(ctx, query, builder.args...)
 	if err != nil {
 		return nil, fmt.Errorf("query: %v", err)
 	}
 	defer rows.Close()
 
-	var results []*model.Company
+	for rows.Next() {
+		var company model.Company
+
+		err = rows.Scan(companyFields(&company)...)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		page.Items = append(page.Items, &company)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		last := page.Items[len(page.Items)-1]
+		cursor := &model.Cursor{ID: last.ID}
+		if column == "name" {
+			cursor.Value = last.Name
+		}
+		page.NextCursor = cursor.Encode()
+	}
+
+	return page, nil
+}
+
+// Export streams all companies matching filter to fn in listing order, filter cursor and limit are ignored
+func (c *Company) Export(ctx context.Context, filter *model.CompanyFilter, fn func(company *model.Company) error) error {
+	builder := new(queryBuilder)
+	applyCompanyFilter(builder, filter)
+	column, direction, _ := companySorting(filter)
+	query := fmt.Sprintf("SELECT %s FROM company%s ORDER BY %s",
+		companyColumns, builder.whereClause(), companyOrderBy(column, direction))
+
+	rows, err := conn(ctx, c.db).Query(ctx, query, builder.args...)
+	if err != nil {
+		return fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var company model.Company
 
-		err = rows.Scan(&company.ID, &company.Name)
+		err = rows.Scan(companyFields(&company)...)
+		if err != nil {
+			return fmt.Errorf("scan: %v", err)
+		}
+
+		if err = fn(&company); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows: %v", err)
+	}
+
+	return nil
+}
+
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+	// headlineOptions marks matches by control characters which are replaced by tags after name is escaped
+	headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", HighlightAll=true"
+)
+
+var headlineMarks = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// highlightHTML escapes name highlighted by ts_headline and wraps matches into <mark> tags
+func highlightHTML(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// Search finds companies by full-text match of name prefixes or trigram similarity, most relevant first
+func (c *Company) Search(ctx context.Context, query string, limit int) ([]*model.CompanySearchResult, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, `SELECT `+companyColumns+`,
+			ts_rank(to_tsvector('simple', name), tsq) + word_similarity($1, name) AS rank,
+			ts_headline('simple', name, tsq, $4) AS highlight
+		FROM company, to_tsquery('simple', $2) tsq
+		WHERE deleted_at IS NULL AND (to_tsvector('simple', name) @@ tsq OR name % $1 OR $1 <% name)
+		ORDER BY rank DESC, name, id
+		LIMIT $3`, query, prefixTSQuery(query), limit, headlineOptions)
+	if err != nil {
+		return nil, fmt.Errorf("search: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.CompanySearchResult
+
+	for rows.Next() {
+		var result model.CompanySearchResult
+
+		err = rows.Scan(append(companyFields(&result.Company), &result.Rank, &result.Highlight)...)
 		if err != nil {
 			return nil, fmt.Errorf("scan: %v", err)
 		}
+		result.Highlight = highlightHTML(result.Highlight)
 
-		results = append(results, &company)
+		results = append(results, &result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
 	}
 
 	return results, nil
@@ -59,36 +199,228 @@ func (c *Company) GetAll(ctx context.Context) ([]*model.Company, error) {
 // GetOne gets Company by its uuid
 func (c *Company) GetOne(ctx context.Context, id uuid.UUID) (*model.Company, error) {
 	var company model.Company
-	err := c.db.QueryRow(ctx, "SELECT id, name FROM company WHERE id = $1", id).Scan(&company.ID, &company.Name)
-	return &company, err
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT "+companyColumns+" FROM company WHERE id = $1 AND deleted_at IS NULL", id).
+		Scan(companyFields(&company)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get Company: %v", err)
+	}
+	return &company, nil
+}
+
+// GetForUpdate gets not deleted company by uuid and locks it until the end of transaction of ctx,
+// so that the returned state is the one following changes are applied to
+func (c *Company) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.Company, error) {
+	var company model.Company
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT "+companyColumns+" FROM company WHERE id = $1 AND deleted_at IS NULL FOR UPDATE OF company",
+		id).Scan(companyFields(&company)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get Company: %v", err)
+	}
+	return &company, nil
 }
 
 // Create creates New Company record in db
 func (c *Company) Create(ctx context.Context, company *model.Company) (uuid.UUID, error) {
 	company.ID = uuid.New()
-	_, err := c.db.Exec(ctx, "INSERT INTO company(id, name) VALUES ($1, $2) RETURNING id, name;",
-		company.ID, company.Name)
+	err := conn(ctx, c.db).QueryRow(ctx, `INSERT INTO company(id, name, description, website, industry, founded_year,
+		headcount, address_street, address_city, address_region, address_postal_code, address_country, created_by,
+		parent_id, normalized_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING created_at, updated_at`,
+		company.ID, company.Name, company.Description, company.Website, company.Industry, company.FoundedYear,
+		company.Headcount, company.Address.Street, company.Address.City, company.Address.Region,
+		company.Address.PostalCode, company.Address.Country, company.CreatedBy, company.ParentID,
+		company.NormalizedName).Scan(&company.CreatedAt, &company.UpdatedAt)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("cannot create Company: %v", err)
 	}
+	company.Version = 1
 	return company.ID, err
 }
 
-// Update updates company in db
+// CreateBatch copies new companies into db assigning their ids and creation time
+func (c *Company) CreateBatch(ctx context.Context, companies []*model.Company) error {
+	now := time.Now().UTC()
+	for _, company := range companies {
+		company.ID = uuid.New()
+		company.Version = 1
+		company.CreatedAt = now
+		company.UpdatedAt = now
+	}
+	_, err := conn(ctx, c.db).CopyFrom(ctx, pgx.Identifier{"company"}, []string{"id", "name", "description", "website", "industry",
+		"founded_year", "headcount", "address_street", "address_city", "address_region", "address_postal_code",
+		"address_country", "created_by", "parent_id", "normalized_name", "created_at", "updated_at"},
+		pgx.CopyFromSlice(len(companies), func(i int) ([]interface{}, error) {
+			company := companies[i]
+			return []interface{}{company.ID, company.Name, company.Description, company.Website, company.Industry,
+				company.FoundedYear, company.Headcount, company.Address.Street, company.Address.City,
+				company.Address.Region, company.Address.PostalCode, company.Address.Country, company.CreatedBy,
+				company.ParentID, company.NormalizedName, company.CreatedAt, company.UpdatedAt}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("cannot copy companies: %v", err)
+	}
+	return nil
+}
+
+// Update updates company in db if its version equals company.Version (any version if it is 0)
+// and refreshes company with the stored state
 func (c *Company) Update(ctx context.Context, company *model.Company) error {
-	_, err := c.db.Exec(ctx, "UPDATE company SET name = $2 WHERE id=$1 RETURNING id, name;",
-		company.ID, company.Name)
+	err := conn(ctx, c.db).QueryRow(ctx, `UPDATE company SET name = $2, description = $3, website = $4, industry = $5,
+		founded_year = $6, headcount = $7, address_street = $8, address_city = $9, address_region = $10,
+		address_postal_code = $11, address_country = $12, parent_id = $14, normalized_name = $15,
+		version = version + 1, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND ($13 = 0 OR version = $13)
+		RETURNING `+companyColumns,
+		company.ID, company.Name, company.Description, company.Website, company.Industry, company.FoundedYear,
+		company.Headcount, company.Address.Street, company.Address.City, company.Address.Region,
+		company.Address.PostalCode, company.Address.Country, company.Version, company.ParentID,
+		company.NormalizedName).Scan(companyFields(company)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.notModifiedError(ctx, company.ID)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot update Company: %v", err)
 	}
-	return err
+	return nil
 }
 
-// Delete deletes company from db
-func (c *Company) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := c.db.Exec(ctx, "DELETE FROM company WHERE id = $1", id)
+// Delete marks company as deleted if its version equals given one (any version if it is 0)
+func (c *Company) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	tag, err := conn(ctx, c.db).Exec(ctx, `UPDATE company SET deleted_at = now(), version = version + 1, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`, id, version)
 	if err != nil {
 		return fmt.Errorf("cannot delete Company: %v", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return c.notModifiedError(ctx, id)
+	}
 	return nil
 }
+
+// Restore brings back deleted company
+func (c *Company) Restore(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, c.db).Exec(ctx, `UPDATE company SET deleted_at = NULL, version = version + 1, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("cannot restore Company: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// Purge permanently removes companies deleted before given time and returns their ids
+func (c *Company) Purge(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, "DELETE FROM company WHERE deleted_at < $1 RETURNING id", deletedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("cannot purge companies: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return ids, nil
+}
+
+// companyFields returns scan destinations matching companyColumns
+func companyFields(company *model.Company) []interface{} {
+	return []interface{}{
+		&company.ID, &company.Name, &company.Description, &company.Website, &company.Industry,
+		&company.FoundedYear, &company.Headcount, &company.Address.Street, &company.Address.City,
+		&company.Address.Region, &company.Address.PostalCode, &company.Address.Country, &company.DeletedAt,
+		&company.Version, &company.CreatedBy, &company.ParentID, &company.CreatedAt, &company.UpdatedAt, &company.Tags,
+	}
+}
+
+// notModifiedError explains why conditional modification of company has not affected any row
+func (c *Company) notModifiedError(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM company WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("cannot check Company existence: %v", err)
+	}
+	if exists {
+		return model.ErrVersionConflict
+	}
+	return echo.ErrNotFound
+}
+
+func applyCompanyFilter(builder *queryBuilder, filter *model.CompanyFilter) {
+	if filter.Deleted {
+		builder.where("deleted_at IS NOT NULL")
+	} else {
+		builder.where("deleted_at IS NULL")
+	}
+	if filter.UpdatedSince != nil {
+		builder.where("updated_at >= " + builder.arg(*filter.UpdatedSince))
+	}
+	if filter.CreatedBefore != nil {
+		builder.where("created_at < " + builder.arg(*filter.CreatedBefore))
+	}
+	if len(filter.Tags) > 0 {
+		builder.where(fmt.Sprintf(`id IN (SELECT company_tag.company_id FROM company_tag
+			JOIN tag ON tag.id = company_tag.tag_id WHERE tag.name = ANY(%s)
+			GROUP BY company_tag.company_id HAVING count(1) = %s)`, builder.arg(filter.Tags), builder.arg(len(filter.Tags))))
+	}
+	if filter.Name == "" {
+		return
+	}
+	pattern := likePattern(filter.Name) + "%"
+	if filter.Match == model.MatchContains {
+		pattern = "%" + pattern
+	}
+	builder.where("name ILIKE " + builder.arg(pattern))
+}
+
+// companySorting returns sort column, sort direction and keyset comparison operator
+func companySorting(filter *model.CompanyFilter) (column, direction, comparison string) {
+	column = "name"
+	if filter.SortBy == model.SortByID {
+		column = "id"
+	}
+	if filter.Desc {
+		return column, "DESC", "<"
+	}
+	return column, "ASC", ">"
+}
+
+// companyOrderBy returns ORDER BY expression with id as a tiebreaker
+func companyOrderBy(column, direction string) string {
+	if column == "id" {
+		return "id " + direction
+	}
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
+
+// prefixTSQuery builds tsquery matching every word of user input as a prefix
+func prefixTSQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,117 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"entetry/gotest/internal/model"
+)
+
+// CompanyChangeRepository companies changes feed repository interface
+type CompanyChangeRepository interface {
+	CreateBatch(ctx context.Context, changes []*model.CompanyChange) error
+	GetSince(ctx context.Context, since int64, limit int) ([]*model.CompanyChange, error)
+	Exists(ctx context.Context, seq int64) (bool, error)
+	LastSeq(ctx context.Context) (int64, error)
+	Purge(ctx context.Context, changedBefore time.Time) (int64, error)
+}
+
+// CompanyChange companies changes feed postgres repository struct
+type CompanyChange struct {
+	db *pgxpool.Pool
+}
+
+// NewCompanyChangeRepository creates new companies changes feed repository object
+func NewCompanyChangeRepository(db *pgxpool.Pool) *CompanyChange {
+	return &CompanyChange{db: db}
+}
+
+// changeFeedLock key of advisory lock serializing writers of the changes feed
+const changeFeedLock = 4207001
+
+// CreateBatch appends changes to the feed in transaction of the changes from ctx, sequence numbers are assigned
+// by db and aren't read back. Writers hold the feed lock until commit, so changes become visible in the order of
+// their sequence numbers and a reader can't skip a change whose transaction commits after the reader has seen
+// a greater number. Callers must append changes right before commit to keep the lock short
+func (c *CompanyChange) CreateBatch(ctx context.Context, changes []*model.CompanyChange) error {
+	return conn(ctx, c.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", changeFeedLock)
+		if err != nil {
+			return fmt.Errorf("cannot lock company changes: %v", err)
+		}
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"company_change"}, []string{"type", "company_id", "company"},
+			pgx.CopyFromSlice(len(changes), func(i int) ([]interface{}, error) {
+				change := changes[i]
+				var company interface{}
+				if change.Company != nil {
+					company = change.Company
+				}
+				return []interface{}{change.Type, change.CompanyID, company}, nil
+			}))
+		if err != nil {
+			return fmt.Errorf("cannot copy company changes: %v", err)
+		}
+		return nil
+	})
+}
+
+// GetSince returns up to limit changes with sequence number greater than since in feed order
+func (c *CompanyChange) GetSince(ctx context.Context, since int64, limit int) ([]*model.CompanyChange, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, `SELECT seq, type, company_id, company, changed_at FROM company_change
+		WHERE seq > $1 ORDER BY seq LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var changes []*model.CompanyChange
+
+	for rows.Next() {
+		var change model.CompanyChange
+
+		err = rows.Scan(&change.Seq, &change.Type, &change.CompanyID, &change.Company, &change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		changes = append(changes, &change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return changes, nil
+}
+
+// Exists reports whether change with sequence number is still retained
+func (c *CompanyChange) Exists(ctx context.Context, seq int64) (bool, error) {
+	var exists bool
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM company_change WHERE seq = $1)", seq).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("cannot check company change existence: %v", err)
+	}
+	return exists, nil
+}
+
+// LastSeq returns sequence number of the latest change, 0 if feed is empty
+func (c *CompanyChange) LastSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT coalesce(max(seq), 0) FROM company_change").Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get last company change: %v", err)
+	}
+	return seq, nil
+}
+
+// Purge removes changes published before given time and returns their number
+func (c *CompanyChange) Purge(ctx context.Context, changedBefore time.Time) (int64, error) {
+	tag, err := conn(ctx, c.db).Exec(ctx, "DELETE FROM company_change WHERE changed_at < $1", changedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("cannot purge company changes: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,99 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// GetAncestors returns chain of not deleted parents of company, nearest parent first
+func (c *Company) GetAncestors(ctx context.Context, id uuid.UUID) ([]*model.Company, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, `WITH RECURSIVE ancestor(ancestor_id, depth) AS (
+			SELECT parent_id, 1 FROM company WHERE id = $1 AND parent_id IS NOT NULL
+			UNION ALL
+			SELECT parent.parent_id, ancestor.depth + 1 FROM company parent
+			JOIN ancestor ON parent.id = ancestor.ancestor_id
+			WHERE parent.parent_id IS NOT NULL AND parent.deleted_at IS NULL AND ancestor.depth < $2
+		)
+		SELECT `+companyColumns+` FROM company JOIN ancestor ON id = ancestor_id
+		WHERE deleted_at IS NULL ORDER BY depth`, id, model.MaxHierarchyDepth)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	return scanCompanies(rows)
+}
+
+// hierarchyLock key of advisory lock serializing changes of company parents
+const hierarchyLock = 4207002
+
+// LockHierarchy locks company hierarchy until the end of transaction of ctx, so that concurrent parent changes
+// can't together form a cycle which none of them sees
+func (c *Company) LockHierarchy(ctx context.Context) error {
+	_, err := conn(ctx, c.db).Exec(ctx, "SELECT pg_advisory_xact_lock($1)", hierarchyLock)
+	if err != nil {
+		return fmt.Errorf("cannot lock company hierarchy: %v", err)
+	}
+	return nil
+}
+
+// IsAncestor reports whether company ancestorID is in parents chain of company id. Deleted parents are walked
+// through as well because they can be restored
+func (c *Company) IsAncestor(ctx context.Context, id, ancestorID uuid.UUID) (bool, error) {
+	var found bool
+	err := conn(ctx, c.db).QueryRow(ctx, `WITH RECURSIVE ancestor(ancestor_id, depth) AS (
+			SELECT parent_id, 1 FROM company WHERE id = $1 AND parent_id IS NOT NULL
+			UNION ALL
+			SELECT parent.parent_id, ancestor.depth + 1 FROM company parent
+			JOIN ancestor ON parent.id = ancestor.ancestor_id
+			WHERE parent.parent_id IS NOT NULL AND ancestor.ancestor_id <> $2 AND ancestor.depth < $3
+		)
+		SELECT EXISTS(SELECT 1 FROM ancestor WHERE ancestor_id = $2)`, id, ancestorID, model.MaxHierarchyDepth).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("cannot check company ancestor: %v", err)
+	}
+	return found, nil
+}
+
+// GetSubsidiaries returns not deleted subsidiaries of company up to given depth, ordered by depth and name
+func (c *Company) GetSubsidiaries(ctx context.Context, id uuid.UUID, depth int) ([]*model.Company, error) {
+	rows, err := conn(ctx, c.db).Query(ctx, `WITH RECURSIVE subsidiary(subsidiary_id, depth) AS (
+			SELECT id, 1 FROM company WHERE parent_id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT child.id, subsidiary.depth + 1 FROM company child
+			JOIN subsidiary ON child.parent_id = subsidiary.subsidiary_id
+			WHERE child.deleted_at IS NULL AND subsidiary.depth < $2
+		)
+		SELECT `+companyColumns+` FROM company JOIN subsidiary ON id = subsidiary_id
+		ORDER BY depth, name, id`, id, depth)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	return scanCompanies(rows)
+}
+
+// scanCompanies reads all companyColumns rows and closes them
+func scanCompanies(rows pgx.Rows) ([]*model.Company, error) {
+	defer rows.Close()
+
+	var companies []*model.Company
+
+	for rows.Next() {
+		var company model.Company
+
+		err := rows.Scan(companyFields(&company)...)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		companies = append(companies, &company)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return companies, nil
+}
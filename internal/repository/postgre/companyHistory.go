@@ -0,0 +1,106 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"entetry/gotest/internal/model"
+)
+
+// CompanyHistoryRepository company history repository interface
+type CompanyHistoryRepository interface {
+	Create(ctx context.Context, entry *model.CompanyHistory) error
+	CreateBatch(ctx context.Context, entries []*model.CompanyHistory) error
+	GetByCompanyID(ctx context.Context, companyID uuid.UUID, cursor *model.Cursor, limit int) (*model.CompanyHistoryPage, error)
+}
+
+// CompanyHistory company history postgres repository struct
+type CompanyHistory struct {
+	db *pgxpool.Pool
+}
+
+// NewCompanyHistoryRepository creates new company history repository object
+func NewCompanyHistoryRepository(db *pgxpool.Pool) *CompanyHistory {
+	return &CompanyHistory{db: db}
+}
+
+// Create inserts company history record in db
+func (h *CompanyHistory) Create(ctx context.Context, entry *model.CompanyHistory) error {
+	entry.ID = uuid.New()
+	err := conn(ctx, h.db).QueryRow(ctx, `INSERT INTO company_history (id, company_id, user_id, action, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING changed_at`,
+		entry.ID, entry.CompanyID, entry.UserID, entry.Action, entry.Before, entry.After).Scan(&entry.ChangedAt)
+	if err != nil {
+		return fmt.Errorf("cannot create company history: %v", err)
+	}
+	return nil
+}
+
+// CreateBatch copies company history records into db
+func (h *CompanyHistory) CreateBatch(ctx context.Context, entries []*model.CompanyHistory) error {
+	for _, entry := range entries {
+		entry.ID = uuid.New()
+	}
+	_, err := conn(ctx, h.db).CopyFrom(ctx, pgx.Identifier{"company_history"},
+		[]string{"id", "company_id", "user_id", "action", "before", "after"},
+		pgx.CopyFromSlice(len(entries), func(i int) ([]interface{}, error) {
+			entry := entries[i]
+			return []interface{}{entry.ID, entry.CompanyID, entry.UserID, entry.Action, entry.Before, entry.After}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("cannot copy company history: %v", err)
+	}
+	return nil
+}
+
+// GetByCompanyID returns page of company history, newest changes first
+func (h *CompanyHistory) GetByCompanyID(ctx context.Context, companyID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CompanyHistoryPage, error) {
+	builder := new(queryBuilder)
+	builder.where("company_id = " + builder.arg(companyID))
+	if cursor != nil {
+		changedAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		builder.where(fmt.Sprintf("(changed_at, id) < (%s, %s)", builder.arg(changedAt), builder.arg(cursor.ID)))
+	}
+	query := fmt.Sprintf(`SELECT id, company_id, user_id, action, changed_at, before, after
+		FROM company_history%s ORDER BY changed_at DESC, id DESC LIMIT %s`, builder.whereClause(), builder.arg(limit+1))
+
+	rows, err := conn(ctx, h.db).Query(ctx, query, builder.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	page := new(model.CompanyHistoryPage)
+
+	for rows.Next() {
+		var entry model.CompanyHistory
+
+		err = rows.Scan(&entry.ID, &entry.CompanyID, &entry.UserID, &entry.Action, &entry.ChangedAt, &entry.Before, &entry.After)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		page.Items = append(page.Items, &entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.ChangedAt.Format(time.RFC3339Nano), ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+
+	return page, nil
+}
@@ -0,0 +1,125 @@
+package postgre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// CompanyMemberRepository company membership repository interface
+type CompanyMemberRepository interface {
+	Upsert(ctx context.Context, member *model.CompanyMember) error
+	CreateBatch(ctx context.Context, members []*model.CompanyMember) error
+	Delete(ctx context.Context, companyID, userID uuid.UUID) error
+	GetRole(ctx context.Context, companyID, userID uuid.UUID) (string, error)
+	GetByCompanyID(ctx context.Context, companyID uuid.UUID) ([]*model.CompanyMember, error)
+	CountOwnersForUpdate(ctx context.Context, companyID uuid.UUID) (int, error)
+}
+
+// CompanyMember company membership postgres repository struct
+type CompanyMember struct {
+	db *pgxpool.Pool
+}
+
+// NewCompanyMemberRepository creates new company membership repository object
+func NewCompanyMemberRepository(db *pgxpool.Pool) *CompanyMember {
+	return &CompanyMember{db: db}
+}
+
+// Upsert grants role in company to user or changes granted one
+func (m *CompanyMember) Upsert(ctx context.Context, member *model.CompanyMember) error {
+	err := conn(ctx, m.db).QueryRow(ctx, `INSERT INTO company_member (company_id, user_id, role, granted_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (company_id, user_id) DO UPDATE SET role = excluded.role, granted_by = excluded.granted_by,
+		granted_at = now()
+		RETURNING granted_at`, member.CompanyID, member.UserID, member.Role, member.GrantedBy).Scan(&member.GrantedAt)
+	if err != nil {
+		return fmt.Errorf("cannot upsert company member: %v", err)
+	}
+	return nil
+}
+
+// CreateBatch copies memberships of new companies into db
+func (m *CompanyMember) CreateBatch(ctx context.Context, members []*model.CompanyMember) error {
+	_, err := conn(ctx, m.db).CopyFrom(ctx, pgx.Identifier{"company_member"}, []string{"company_id", "user_id", "role", "granted_by"},
+		pgx.CopyFromSlice(len(members), func(i int) ([]interface{}, error) {
+			member := members[i]
+			return []interface{}{member.CompanyID, member.UserID, member.Role, member.GrantedBy}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("cannot copy company members: %v", err)
+	}
+	return nil
+}
+
+// Delete revokes user membership in company
+func (m *CompanyMember) Delete(ctx context.Context, companyID, userID uuid.UUID) error {
+	tag, err := conn(ctx, m.db).Exec(ctx, "DELETE FROM company_member WHERE company_id = $1 AND user_id = $2", companyID, userID)
+	if err != nil {
+		return fmt.Errorf("cannot delete company member: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// GetRole returns user role in company, empty string if user isn't a member
+func (m *CompanyMember) GetRole(ctx context.Context, companyID, userID uuid.UUID) (string, error) {
+	var role string
+	err := conn(ctx, m.db).QueryRow(ctx, "SELECT role FROM company_member WHERE company_id = $1 AND user_id = $2",
+		companyID, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get company member role: %v", err)
+	}
+	return role, nil
+}
+
+// GetByCompanyID returns all members of company
+func (m *CompanyMember) GetByCompanyID(ctx context.Context, companyID uuid.UUID) ([]*model.CompanyMember, error) {
+	rows, err := conn(ctx, m.db).Query(ctx, `SELECT company_id, user_id, role, granted_by, granted_at FROM company_member
+		WHERE company_id = $1 ORDER BY granted_at, user_id`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var members []*model.CompanyMember
+
+	for rows.Next() {
+		var member model.CompanyMember
+
+		err = rows.Scan(&member.CompanyID, &member.UserID, &member.Role, &member.GrantedBy, &member.GrantedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		members = append(members, &member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return members, nil
+}
+
+// CountOwnersForUpdate returns count of company owners and locks their memberships until the end of transaction,
+// so that concurrent changes of owners can't leave company without one
+func (m *CompanyMember) CountOwnersForUpdate(ctx context.Context, companyID uuid.UUID) (int, error) {
+	var count int
+	err := conn(ctx, m.db).QueryRow(ctx, `SELECT count(1) FROM (SELECT user_id FROM company_member
+		WHERE company_id = $1 AND role = $2 FOR UPDATE) owners`, companyID, model.RoleOwner).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("cannot count company owners: %v", err)
+	}
+	return count, nil
+}
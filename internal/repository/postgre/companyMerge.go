@@ -0,0 +1,105 @@
+package postgre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// mergeStatements move records related to source company ($1) to target one ($2)
+var mergeStatements = []string{
+	// source logo versions follow versions of target, source current logo stays current only if target has no logo
+	`UPDATE logo SET company_id = $2,
+		version = version + (SELECT coalesce(max(version), 0) FROM logo WHERE company_id = $2),
+		current = current AND NOT EXISTS (SELECT 1 FROM logo WHERE company_id = $2 AND current)
+		WHERE company_id = $1`,
+	`INSERT INTO company_member (company_id, user_id, role, granted_by, granted_at)
+		SELECT $2, user_id, role, granted_by, granted_at FROM company_member WHERE company_id = $1
+		ON CONFLICT (company_id, user_id) DO NOTHING`,
+	`INSERT INTO company_tag (company_id, tag_id) SELECT $2, tag_id FROM company_tag WHERE company_id = $1
+		ON CONFLICT DO NOTHING`,
+	`UPDATE contact SET company_id = $2 WHERE company_id = $1`,
+	`UPDATE company_comment SET company_id = $2 WHERE company_id = $1`,
+	`INSERT INTO company_watch (user_id, company_id, created_at)
+		SELECT user_id, $2, created_at FROM company_watch WHERE company_id = $1 ON CONFLICT DO NOTHING`,
+	`UPDATE company_alias SET company_id = $2 WHERE company_id = $1`,
+	`INSERT INTO company_alias (alias_id, company_id) VALUES ($1, $2)`,
+}
+
+// Merge moves logo versions, members, tags, contacts, comments, watchers, subsidiaries and aliases of source company
+// to target, removes source and keeps its id as an alias of target. Caller must hold locks of both not deleted
+// companies taken by GetForUpdate
+func (c *Company) Merge(ctx context.Context, sourceID, targetID uuid.UUID) (*model.CompanyMerge, error) {
+	merge := new(model.CompanyMerge)
+	err := conn(ctx, c.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		for _, statement := range mergeStatements {
+			if _, err := tx.Exec(ctx, statement, sourceID, targetID); err != nil {
+				return fmt.Errorf("cannot merge company records: %v", err)
+			}
+		}
+
+		rows, err := tx.Query(ctx, `UPDATE company SET parent_id = $2, version = version + 1, updated_at = now() WHERE parent_id = $1
+			RETURNING `+companyColumns, sourceID, targetID)
+		if err != nil {
+			return fmt.Errorf("cannot move subsidiaries: %v", err)
+		}
+		merge.Subsidiaries, err = scanCompanies(rows)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(ctx, "DELETE FROM company WHERE id = $1", sourceID); err != nil {
+			return fmt.Errorf("cannot delete merged Company: %v", err)
+		}
+		if _, err = tx.Exec(ctx, "UPDATE company SET version = version + 1, updated_at = now() WHERE id = $1", targetID); err != nil {
+			return fmt.Errorf("cannot update Company version: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// ResolveAlias returns id of company which company with given id has been merged into
+func (c *Company) ResolveAlias(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	var companyID uuid.UUID
+	err := conn(ctx, c.db).QueryRow(ctx, "SELECT company_id FROM company_alias WHERE alias_id = $1", id).Scan(&companyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("cannot resolve company alias: %v", err)
+	}
+	return companyID, nil
+}
+
+// scanStrings reads all rows of single text column and closes them
+func scanStrings(rows pgx.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value string
+
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return values, nil
+}
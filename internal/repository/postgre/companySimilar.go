@@ -0,0 +1,51 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// FindSimilar returns not deleted companies which normalized names have trigram similarity to the given one
+// at least threshold, most similar first
+func (c *Company) FindSimilar(ctx context.Context, normalizedName string, excludeID uuid.UUID, threshold float64,
+	limit int) ([]*model.CompanyMatch, error) {
+	var matches []*model.CompanyMatch
+	err := conn(ctx, c.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		// % operator uses the threshold setting and unlike similarity() function can use trigram index
+		_, err := tx.Exec(ctx, "SELECT set_config('pg_trgm.similarity_threshold', $1, true)", fmt.Sprint(threshold))
+		if err != nil {
+			return fmt.Errorf("cannot set similarity threshold: %v", err)
+		}
+		rows, err := tx.Query(ctx, `SELECT `+companyColumns+`, similarity(normalized_name, $1) AS similarity
+			FROM company WHERE deleted_at IS NULL AND id <> $2 AND normalized_name % $1
+			ORDER BY similarity DESC, name, id LIMIT $3`, normalizedName, excludeID, limit)
+		if err != nil {
+			return fmt.Errorf("query: %v", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var match model.CompanyMatch
+
+			err = rows.Scan(append(companyFields(&match.Company), &match.Similarity)...)
+			if err != nil {
+				return fmt.Errorf("scan: %v", err)
+			}
+
+			matches = append(matches, &match)
+		}
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("rows: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
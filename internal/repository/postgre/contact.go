@@ -0,0 +1,138 @@
+package postgre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// ContactRepository company contacts repository interface
+type ContactRepository interface {
+	Create(ctx context.Context, contact *model.Contact) error
+	Update(ctx context.Context, contact *model.Contact) error
+	Delete(ctx context.Context, companyID, id uuid.UUID) error
+	GetOne(ctx context.Context, companyID, id uuid.UUID) (*model.Contact, error)
+	GetByCompanyID(ctx context.Context, companyID uuid.UUID, cursor *model.Cursor, limit int) (*model.ContactPage, error)
+}
+
+const contactColumns = "id, company_id, name, role, email, phone, created_at"
+
+// Contact company contacts postgres repository struct
+type Contact struct {
+	db *pgxpool.Pool
+}
+
+// NewContactRepository creates new company contacts repository object
+func NewContactRepository(db *pgxpool.Pool) *Contact {
+	return &Contact{db: db}
+}
+
+// Create inserts contact in db
+func (c *Contact) Create(ctx context.Context, contact *model.Contact) error {
+	contact.ID = uuid.New()
+	err := c.db.QueryRow(ctx, `INSERT INTO contact (id, company_id, name, role, email, phone)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`,
+		contact.ID, contact.CompanyID, contact.Name, contact.Role, contact.Email, contact.Phone).Scan(&contact.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot create contact: %v", err)
+	}
+	return nil
+}
+
+// Update updates contact of company and refreshes contact with the stored state
+func (c *Contact) Update(ctx context.Context, contact *model.Contact) error {
+	err := c.db.QueryRow(ctx, `UPDATE contact SET name = $3, role = $4, email = $5, phone = $6
+		WHERE company_id = $1 AND id = $2 RETURNING `+contactColumns,
+		contact.CompanyID, contact.ID, contact.Name, contact.Role, contact.Email, contact.Phone).
+		Scan(contactFields(contact)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return echo.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update contact: %v", err)
+	}
+	return nil
+}
+
+// Delete deletes contact of company
+func (c *Contact) Delete(ctx context.Context, companyID, id uuid.UUID) error {
+	tag, err := c.db.Exec(ctx, "DELETE FROM contact WHERE company_id = $1 AND id = $2", companyID, id)
+	if err != nil {
+		return fmt.Errorf("cannot delete contact: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// GetOne gets contact of company by its id
+func (c *Contact) GetOne(ctx context.Context, companyID, id uuid.UUID) (*model.Contact, error) {
+	var contact model.Contact
+	err := c.db.QueryRow(ctx, "SELECT "+contactColumns+" FROM contact WHERE company_id = $1 AND id = $2", companyID, id).
+		Scan(contactFields(&contact)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get contact: %v", err)
+	}
+	return &contact, nil
+}
+
+// GetByCompanyID returns page of company contacts ordered by name
+func (c *Contact) GetByCompanyID(ctx context.Context, companyID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.ContactPage, error) {
+	builder := new(queryBuilder)
+	builder.where("company_id = " + builder.arg(companyID))
+	if cursor != nil {
+		builder.where(fmt.Sprintf("(name, id) > (%s, %s)", builder.arg(cursor.Value), builder.arg(cursor.ID)))
+	}
+	query := fmt.Sprintf("SELECT %s FROM contact%s ORDER BY name, id LIMIT %s",
+		contactColumns, builder.whereClause(), builder.arg(limit+1))
+
+	rows, err := c.db.Query(ctx, query, builder.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	page := new(model.ContactPage)
+
+	for rows.Next() {
+		var contact model.Contact
+
+		err = rows.Scan(contactFields(&contact)...)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		page.Items = append(page.Items, &contact)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.Name, ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+
+	return page, nil
+}
+
+// contactFields returns scan destinations matching contactColumns
+func contactFields(contact *model.Contact) []interface{} {
+	return []interface{}{
+		&contact.ID, &contact.CompanyID, &contact.Name, &contact.Role, &contact.Email, &contact.Phone, &contact.CreatedAt,
+	}
+}
@@ -7,14 +7,23 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
 
 	"entetry/gotest/internal/model"
 )
 
+const logoColumns = "id, company_id, version, current, image, content_type, size, created_at, created_by"
+
 // LogoRepository company logo repository interface
 type LogoRepository interface {
-	Create(ctx context.Context, companyID uuid.UUID, url string) error
+	Create(ctx context.Context, logo *model.Logo) error
 	GetByCompanyID(ctx context.Context, companyID uuid.UUID) (*model.Logo, error)
+	GetVersions(ctx context.Context, companyID uuid.UUID) ([]*model.Logo, error)
+	GetVariants(ctx context.Context, logoID uuid.UUID) ([]*model.LogoVariant, error)
+	SetCurrent(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error)
+	ClearCurrent(ctx context.Context, companyID uuid.UUID) (*model.Logo, error)
+	Prune(ctx context.Context, companyID uuid.UUID, keep int) ([]string, error)
+	DeleteByCompanyID(ctx context.Context, companyID uuid.UUID) ([]string, error)
 }
 
 // Logo company logo postgres repository struct
@@ -28,31 +37,184 @@ func NewLogoRepository(db *pgxpool.Pool) *Logo {
 		db: db}
 }
 
-// Create creates company logo record in db
-func (l *Logo) Create(ctx context.Context, companyID uuid.UUID, url string) error {
+// Create creates next logo version of company together with its variants and makes it current,
+// logo ID is assigned by caller because it is a part of image keys
+func (l *Logo) Create(ctx context.Context, logo *model.Logo) error {
+	return conn(ctx, l.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		// serializes uploads to the same company so that versions don't collide,
+		// and keeps logo from being added to company which is being purged
+		var locked int
+		err := tx.QueryRow(ctx, "SELECT 1 FROM company WHERE id = $1 FOR UPDATE", logo.CompanyID).Scan(&locked)
+		if err == pgx.ErrNoRows {
+			return echo.ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("cannot lock Company: %v", err)
+		}
+		err = tx.QueryRow(ctx, "SELECT coalesce(max(version), 0) + 1 FROM logo WHERE company_id = $1", logo.CompanyID).
+			Scan(&logo.Version)
+		if err != nil {
+			return fmt.Errorf("cannot get Logo version: %v", err)
+		}
+		_, err = tx.Exec(ctx, "UPDATE logo SET current = false WHERE company_id = $1 AND current", logo.CompanyID)
+		if err != nil {
+			return fmt.Errorf("cannot update Logo: %v", err)
+		}
+		logo.Current = true
+		err = tx.QueryRow(ctx, `INSERT INTO logo (id, company_id, version, current, image, content_type, size, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at`, logo.ID, logo.CompanyID, logo.Version, logo.Current,
+			logo.Image, logo.ContentType, logo.Size, logo.CreatedBy).Scan(&logo.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("cannot create Logo: %v", err)
+		}
+		for _, variant := range logo.Variants {
+			variant.LogoID = logo.ID
+			_, err = tx.Exec(ctx, `INSERT INTO logo_variant (logo_id, dimension, width, height, image, content_type, size)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`, variant.LogoID, variant.Dimension, variant.Width, variant.Height,
+				variant.Image, variant.ContentType, variant.Size)
+			if err != nil {
+				return fmt.Errorf("cannot create LogoVariant: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetByCompanyID gets current company logo by company uuid, variants aren't loaded
+func (l *Logo) GetByCompanyID(ctx context.Context, companyID uuid.UUID) (*model.Logo, error) {
 	var logo model.Logo
-	logo.ID = uuid.New()
-	logo.CompanyID = companyID
-	logo.Image = url
-	_, err := l.db.Exec(ctx, `INSERT INTO logo (id, company_id, image) VALUES ($1, $2, $3)`, logo.ID,
-		logo.CompanyID, logo.Image)
+	err := scanLogo(conn(ctx, l.db).QueryRow(ctx, "SELECT "+logoColumns+" FROM logo WHERE company_id = $1 AND current",
+		companyID), &logo)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetByCompanyID failed: %v", err)
+	}
+	return &logo, nil
+}
 
+// GetVersions gets all logo versions of company, newest first
+func (l *Logo) GetVersions(ctx context.Context, companyID uuid.UUID) ([]*model.Logo, error) {
+	rows, err := conn(ctx, l.db).Query(ctx, "SELECT "+logoColumns+" FROM logo WHERE company_id = $1 ORDER BY version DESC",
+		companyID)
 	if err != nil {
-		return fmt.Errorf("cannot create Logo: %v", err)
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var logos []*model.Logo
+	for rows.Next() {
+		var logo model.Logo
+		if err = scanLogo(rows, &logo); err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		logos = append(logos, &logo)
 	}
-	return nil
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+	return logos, nil
 }
 
-// GetByCompanyID gets company logo by company uuid
-func (l *Logo) GetByCompanyID(ctx context.Context, companyID uuid.UUID) (*model.Logo, error) {
+// GetVariants gets variants of logo ordered by dimension
+func (l *Logo) GetVariants(ctx context.Context, logoID uuid.UUID) ([]*model.LogoVariant, error) {
+	rows, err := conn(ctx, l.db).Query(ctx, `SELECT logo_id, dimension, width, height, image, content_type, size
+		FROM logo_variant WHERE logo_id = $1 ORDER BY dimension`, logoID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var variants []*model.LogoVariant
+	for rows.Next() {
+		var variant model.LogoVariant
+		err = rows.Scan(&variant.LogoID, &variant.Dimension, &variant.Width, &variant.Height, &variant.Image,
+			&variant.ContentType, &variant.Size)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		variants = append(variants, &variant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+	return variants, nil
+}
+
+// SetCurrent makes given logo version current and returns it, echo.ErrNotFound if company has no such version
+func (l *Logo) SetCurrent(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error) {
+	var logo model.Logo
+	err := conn(ctx, l.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "UPDATE logo SET current = false WHERE company_id = $1 AND current AND version <> $2",
+			companyID, version)
+		if err != nil {
+			return fmt.Errorf("cannot update Logo: %v", err)
+		}
+		err = scanLogo(tx.QueryRow(ctx, "UPDATE logo SET current = true WHERE company_id = $1 AND version = $2 RETURNING "+
+			logoColumns, companyID, version), &logo)
+		if err == pgx.ErrNoRows {
+			return echo.ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("cannot update Logo: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &logo, nil
+}
+
+// ClearCurrent leaves company without logo keeping its versions, returns previously current logo
+// or echo.ErrNotFound if company has no logo
+func (l *Logo) ClearCurrent(ctx context.Context, companyID uuid.UUID) (*model.Logo, error) {
 	var logo model.Logo
-	err := l.db.QueryRow(ctx, "SELECT id, company_id, image FROM logo WHERE company_id = $1", companyID).
-		Scan(&logo.ID, &logo.CompanyID, &logo.Image)
+	err := scanLogo(conn(ctx, l.db).QueryRow(ctx, "UPDATE logo SET current = false WHERE company_id = $1 AND current RETURNING "+
+		logoColumns, companyID), &logo)
 	if err == pgx.ErrNoRows {
-		return nil, nil
+		return nil, echo.ErrNotFound
 	}
 	if err != nil {
-		return nil, fmt.Errorf("GetByCompanyID failed: %v", err)
+		return nil, fmt.Errorf("cannot update Logo: %v", err)
 	}
 	return &logo, nil
 }
+
+// Prune deletes versions of company logo except for current one and latest ones, so that at most keep versions
+// remain counting the current one, returns storage keys of deleted images
+func (l *Logo) Prune(ctx context.Context, companyID uuid.UUID, keep int) ([]string, error) {
+	rows, err := conn(ctx, l.db).Query(ctx, `WITH pruned AS (
+			SELECT id FROM logo WHERE company_id = $1 AND NOT current ORDER BY version DESC
+			OFFSET greatest($2 - (SELECT count(1) FROM logo WHERE company_id = $1 AND current), 0)
+		), variants AS (
+			DELETE FROM logo_variant WHERE logo_id IN (SELECT id FROM pruned) RETURNING image
+		), logos AS (
+			DELETE FROM logo WHERE id IN (SELECT id FROM pruned) RETURNING image
+		)
+		SELECT image FROM logos UNION ALL SELECT image FROM variants`, companyID, keep)
+	if err != nil {
+		return nil, fmt.Errorf("cannot prune Logo: %v", err)
+	}
+	return scanStrings(rows)
+}
+
+// DeleteByCompanyID deletes company logo records and returns their images
+func (l *Logo) DeleteByCompanyID(ctx context.Context, companyID uuid.UUID) ([]string, error) {
+	rows, err := conn(ctx, l.db).Query(ctx, `WITH variants AS (
+			DELETE FROM logo_variant WHERE logo_id IN (SELECT id FROM logo WHERE company_id = $1) RETURNING image
+		), logos AS (
+			DELETE FROM logo WHERE company_id = $1 RETURNING image
+		)
+		SELECT image FROM logos UNION ALL SELECT image FROM variants`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot delete Logo: %v", err)
+	}
+	return scanStrings(rows)
+}
+
+func scanLogo(row pgx.Row, logo *model.Logo) error {
+	return row.Scan(&logo.ID, &logo.CompanyID, &logo.Version, &logo.Current, &logo.Image, &logo.ContentType, &logo.Size,
+		&logo.CreatedAt, &logo.CreatedBy)
+}
@@ -0,0 +1,116 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// NotificationRepository watched company notifications repository interface
+type NotificationRepository interface {
+	CreateBatch(ctx context.Context, notification *model.Notification, userIDs []uuid.UUID) error
+	GetByUserID(ctx context.Context, userID uuid.UUID, unread bool, cursor *model.Cursor,
+		limit int) (*model.NotificationPage, error)
+	MarkRead(ctx context.Context, userID, id uuid.UUID) error
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// Notification notifications postgres repository struct
+type Notification struct {
+	db *pgxpool.Pool
+}
+
+// NewNotificationRepository creates new notifications repository object
+func NewNotificationRepository(db *pgxpool.Pool) *Notification {
+	return &Notification{db: db}
+}
+
+// CreateBatch inserts copy of notification for each of users, UserID and ID of notification are ignored
+func (n *Notification) CreateBatch(ctx context.Context, notification *model.Notification, userIDs []uuid.UUID) error {
+	_, err := n.db.Exec(ctx, `INSERT INTO notification (user_id, company_id, company_name, event)
+		SELECT unnest($1::text[])::uuid, $2, $3, $4`,
+		uuidStrings(userIDs), notification.CompanyID, notification.CompanyName, notification.Event)
+	if err != nil {
+		return fmt.Errorf("cannot create notifications: %v", err)
+	}
+	return nil
+}
+
+// GetByUserID returns page of user notifications, newest first
+func (n *Notification) GetByUserID(ctx context.Context, userID uuid.UUID, unread bool, cursor *model.Cursor,
+	limit int) (*model.NotificationPage, error) {
+	builder := new(queryBuilder)
+	builder.where("user_id = " + builder.arg(userID))
+	if unread {
+		builder.where("read_at IS NULL")
+	}
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		builder.where(fmt.Sprintf("(created_at, id) < (%s, %s)", builder.arg(createdAt), builder.arg(cursor.ID)))
+	}
+	query := fmt.Sprintf(`SELECT id, user_id, company_id, company_name, event, created_at, read_at
+		FROM notification%s ORDER BY created_at DESC, id DESC LIMIT %s`, builder.whereClause(), builder.arg(limit+1))
+
+	rows, err := n.db.Query(ctx, query, builder.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	page := new(model.NotificationPage)
+
+	for rows.Next() {
+		var notification model.Notification
+
+		err = rows.Scan(&notification.ID, &notification.UserID, &notification.CompanyID, &notification.CompanyName,
+			&notification.Event, &notification.CreatedAt, &notification.ReadAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		page.Items = append(page.Items, &notification)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+
+	return page, nil
+}
+
+// MarkRead marks user notification as read, marking already read notification is not an error
+func (n *Notification) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	tag, err := n.db.Exec(ctx, `UPDATE notification SET read_at = coalesce(read_at, now())
+		WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return fmt.Errorf("cannot mark notification read: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks all unread notifications of user as read and returns their number
+func (n *Notification) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	tag, err := n.db.Exec(ctx, "UPDATE notification SET read_at = now() WHERE user_id = $1 AND read_at IS NULL", userID)
+	if err != nil {
+		return 0, fmt.Errorf("cannot mark notifications read: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,34 @@
+package postgre
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryBuilder accumulates sql conditions and their positional arguments
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg registers argument and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// likePattern escapes LIKE wildcards in user input
+func likePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
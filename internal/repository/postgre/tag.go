@@ -0,0 +1,109 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// TagRepository company tags repository interface
+type TagRepository interface {
+	AddToCompany(ctx context.Context, companyID uuid.UUID, names []string) error
+	RemoveFromCompany(ctx context.Context, companyID uuid.UUID, name string) error
+	Autocomplete(ctx context.Context, prefix string, limit int) ([]*model.Tag, error)
+}
+
+// Tag company tags postgres repository struct
+type Tag struct {
+	db *pgxpool.Pool
+}
+
+// NewTagRepository creates new company tags repository object
+func NewTagRepository(db *pgxpool.Pool) *Tag {
+	return &Tag{db: db}
+}
+
+// AddToCompany labels not deleted company by tags creating missing ones, company version is incremented
+func (t *Tag) AddToCompany(ctx context.Context, companyID uuid.UUID, names []string) error {
+	return conn(ctx, t.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		err := bumpCompanyVersion(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO tag (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", names)
+		if err != nil {
+			return fmt.Errorf("cannot create tags: %v", err)
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO company_tag (company_id, tag_id) SELECT $1, id FROM tag WHERE name = ANY($2)
+			ON CONFLICT DO NOTHING`, companyID, names)
+		if err != nil {
+			return fmt.Errorf("cannot add company tags: %v", err)
+		}
+		return nil
+	})
+}
+
+// RemoveFromCompany removes tag from not deleted company, company version is incremented
+func (t *Tag) RemoveFromCompany(ctx context.Context, companyID uuid.UUID, name string) error {
+	return conn(ctx, t.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM company_tag USING tag
+			WHERE company_tag.tag_id = tag.id AND company_tag.company_id = $1 AND tag.name = $2`, companyID, name)
+		if err != nil {
+			return fmt.Errorf("cannot remove company tag: %v", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return echo.ErrNotFound
+		}
+		return bumpCompanyVersion(ctx, tx, companyID)
+	})
+}
+
+// Autocomplete returns tags starting with prefix, most used first
+func (t *Tag) Autocomplete(ctx context.Context, prefix string, limit int) ([]*model.Tag, error) {
+	rows, err := conn(ctx, t.db).Query(ctx, `SELECT tag.name, count(1) AS companies FROM tag
+		JOIN company_tag ON company_tag.tag_id = tag.id
+		JOIN company ON company.id = company_tag.company_id AND company.deleted_at IS NULL
+		WHERE tag.name LIKE $1
+		GROUP BY tag.name ORDER BY companies DESC, tag.name LIMIT $2`, likePattern(prefix)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var tags []*model.Tag
+
+	for rows.Next() {
+		var tag model.Tag
+
+		err = rows.Scan(&tag.Name, &tag.Companies)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		tags = append(tags, &tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return tags, nil
+}
+
+// bumpCompanyVersion increments version of not deleted company changed outside of company table
+func bumpCompanyVersion(ctx context.Context, tx pgx.Tx, companyID uuid.UUID) error {
+	tag, err := tx.Exec(ctx, `UPDATE company SET version = version + 1, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL`, companyID)
+	if err != nil {
+		return fmt.Errorf("cannot update Company version: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
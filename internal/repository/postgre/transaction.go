@@ -0,0 +1,52 @@
+package postgre
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Transactor runs functions in database transaction
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// querier is implemented by both connection pool and transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string,
+		rowSrc pgx.CopyFromSource) (int64, error)
+	BeginFunc(ctx context.Context, f func(pgx.Tx) error) error
+}
+
+type txKey struct{}
+
+// Transaction postgres transactor struct
+type Transaction struct {
+	db *pgxpool.Pool
+}
+
+// NewTransactor creates new postgres transactor object
+func NewTransactor(db *pgxpool.Pool) *Transaction {
+	return &Transaction{db: db}
+}
+
+// InTx runs fn in transaction which is committed if fn returns nil. Company repositories called with
+// the context passed to fn take part in the transaction, nested calls run in savepoints
+func (t *Transaction) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, t.db).BeginFunc(ctx, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns transaction started by InTx for ctx, db pool otherwise
+func conn(ctx context.Context, db *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -16,6 +16,7 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, username, pwdHash, email string) (uuid.UUID, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error)
 }
 
 // User User postgres repository struct
@@ -60,3 +61,31 @@ func (u *User) GetByUsername(ctx context.Context, username string) (*model.User,
 	}
 	return &user, nil
 }
+
+// GetByUsernames returns existing users with given usernames
+func (u *User) GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error) {
+	rows, err := u.db.Query(ctx,
+		`SELECT id, username, email, passwordHash FROM users WHERE username = ANY($1)`, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var users []*model.User
+
+	for rows.Next() {
+		var user model.User
+
+		err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return users, nil
+}
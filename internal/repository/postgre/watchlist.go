@@ -0,0 +1,116 @@
+package postgre
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// WatchlistRepository followed companies repository interface
+type WatchlistRepository interface {
+	Add(ctx context.Context, userID, companyID uuid.UUID) error
+	Remove(ctx context.Context, userID, companyID uuid.UUID) error
+	GetCompanies(ctx context.Context, userID uuid.UUID, cursor *model.Cursor, limit int) (*model.CompanyPage, error)
+	GetWatchers(ctx context.Context, companyID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// Watchlist followed companies postgres repository struct
+type Watchlist struct {
+	db *pgxpool.Pool
+}
+
+// NewWatchlistRepository creates new watchlist repository object
+func NewWatchlistRepository(db *pgxpool.Pool) *Watchlist {
+	return &Watchlist{db: db}
+}
+
+// Add adds company to user watchlist, watching already followed company is not an error
+func (w *Watchlist) Add(ctx context.Context, userID, companyID uuid.UUID) error {
+	_, err := w.db.Exec(ctx, `INSERT INTO company_watch (user_id, company_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		userID, companyID)
+	if err != nil {
+		return fmt.Errorf("cannot watch company: %v", err)
+	}
+	return nil
+}
+
+// Remove removes company from user watchlist
+func (w *Watchlist) Remove(ctx context.Context, userID, companyID uuid.UUID) error {
+	tag, err := w.db.Exec(ctx, "DELETE FROM company_watch WHERE user_id = $1 AND company_id = $2", userID, companyID)
+	if err != nil {
+		return fmt.Errorf("cannot unwatch company: %v", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return echo.ErrNotFound
+	}
+	return nil
+}
+
+// GetCompanies returns page of not deleted companies watched by user ordered by name
+func (w *Watchlist) GetCompanies(ctx context.Context, userID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CompanyPage, error) {
+	builder := new(queryBuilder)
+	builder.where("deleted_at IS NULL")
+	builder.where("id IN (SELECT company_id FROM company_watch WHERE user_id = " + builder.arg(userID) + ")")
+
+	page := new(model.CompanyPage)
+	err := w.db.QueryRow(ctx, "SELECT count(1) FROM company"+builder.whereClause(), builder.args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count: %v", err)
+	}
+
+	if cursor != nil {
+		builder.where(fmt.Sprintf("(name, id) > (%s, %s)", builder.arg(cursor.Value), builder.arg(cursor.ID)))
+	}
+	query := fmt.Sprintf("SELECT %s FROM company%s ORDER BY %s LIMIT %s",
+		companyColumns, builder.whereClause(), companyOrderBy("name", "ASC"), builder.arg(limit+1))
+
+	rows, err := w.db.Query(ctx, query, builder.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	page.Items, err = scanCompanies(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		next := &model.Cursor{Value: last.Name, ID: last.ID}
+		page.NextCursor = next.Encode()
+	}
+	return page, nil
+}
+
+// GetWatchers returns ids of users watching company
+func (w *Watchlist) GetWatchers(ctx context.Context, companyID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := w.db.Query(ctx, "SELECT user_id FROM company_watch WHERE company_id = $1", companyID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %v", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/repository/postgre"
+)
+
+// mentionPattern matches @username not preceded by word characters, so e-mail addresses aren't mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]{3,32})`)
+
+// Comment company comments service struct
+type Comment struct {
+	commentRepository postgre.CommentRepository
+	companyRepository postgre.CompanyRepository
+	users             *User
+	members           *Member
+}
+
+// NewComment creates new company comments service
+func NewComment(commentRepository postgre.CommentRepository, companyRepository postgre.CompanyRepository,
+	users *User, members *Member) *Comment {
+	return &Comment{
+		commentRepository: commentRepository,
+		companyRepository: companyRepository,
+		users:             users,
+		members:           members,
+	}
+}
+
+// GetAll returns page of top level comments of not deleted company, requires viewer role
+func (c *Comment) GetAll(ctx context.Context, userID, companyID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CommentPage, error) {
+	err := c.authorize(ctx, userID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return c.commentRepository.GetByParent(ctx, companyID, nil, cursor, limit)
+}
+
+// GetReplies returns page of replies to comment, requires viewer role
+func (c *Comment) GetReplies(ctx context.Context, userID, companyID, id uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CommentPage, error) {
+	err := c.authorize(ctx, userID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = c.commentRepository.GetOne(ctx, companyID, id); err != nil {
+		return nil, err
+	}
+	return c.commentRepository.GetByParent(ctx, companyID, &id, cursor, limit)
+}
+
+// Create adds comment authored by user, replies are allowed only to not deleted comments of the same company.
+// Requires viewer role
+func (c *Comment) Create(ctx context.Context, comment *model.Comment) error {
+	err := c.authorize(ctx, comment.AuthorID, comment.CompanyID)
+	if err != nil {
+		return err
+	}
+	if comment.ParentID != nil {
+		parent, err := c.commentRepository.GetOne(ctx, comment.CompanyID, *comment.ParentID)
+		if errors.Is(err, echo.ErrNotFound) {
+			return model.ErrParentCommentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if parent.DeletedAt != nil {
+			return model.ErrParentCommentNotFound
+		}
+	}
+	comment.Mentions, err = c.mentions(ctx, comment.Body)
+	if err != nil {
+		return err
+	}
+	return c.commentRepository.Create(ctx, comment)
+}
+
+// Update changes body of comment, only its author can do it
+func (c *Comment) Update(ctx context.Context, userID uuid.UUID, comment *model.Comment) error {
+	err := c.authorizeAuthor(ctx, userID, comment.CompanyID, comment.ID)
+	if err != nil {
+		return err
+	}
+	comment.Mentions, err = c.mentions(ctx, comment.Body)
+	if err != nil {
+		return err
+	}
+	return c.commentRepository.Update(ctx, comment)
+}
+
+// Delete replaces comment by a tombstone, only its author can do it
+func (c *Comment) Delete(ctx context.Context, userID, companyID, id uuid.UUID) error {
+	err := c.authorizeAuthor(ctx, userID, companyID, id)
+	if err != nil {
+		return err
+	}
+	return c.commentRepository.Delete(ctx, companyID, id)
+}
+
+// authorize checks that user can view not deleted company
+func (c *Comment) authorize(ctx context.Context, userID, companyID uuid.UUID) error {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleViewer)
+	if err != nil {
+		return err
+	}
+	_, err = c.companyRepository.GetOne(ctx, companyID)
+	return err
+}
+
+// authorizeAuthor checks that user still has access to company and wrote the comment
+func (c *Comment) authorizeAuthor(ctx context.Context, userID, companyID, id uuid.UUID) error {
+	err := c.authorize(ctx, userID, companyID)
+	if err != nil {
+		return err
+	}
+	comment, err := c.commentRepository.GetOne(ctx, companyID, id)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != userID {
+		return model.ErrForbidden
+	}
+	return nil
+}
+
+// mentions returns ids of existing users mentioned in body, unknown usernames are ignored
+func (c *Comment) mentions(ctx context.Context, body string) ([]uuid.UUID, error) {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.TrimRight(match[1], ".")
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	users, err := c.users.GetByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids, nil
+}
@@ -2,50 +2,75 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"mime/multipart"
-	"os"
-	"path/filepath"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 
 	"entetry/gotest/internal/cache"
+	"entetry/gotest/internal/config"
 	"entetry/gotest/internal/event"
 	"entetry/gotest/internal/model"
 	"entetry/gotest/internal/producer"
 	"entetry/gotest/internal/repository/postgre"
+	"entetry/gotest/internal/storage"
 )
 
-const (
-	companyAlreadyHasALogoErr = "company already has a logo"
-	fileSaveError             = "file save error"
-	imageExt                  = ".jpeg"
-)
+const duplicateCandidatesLimit = 5
 
 // Company service company struct
 type Company struct {
 	companyRepository postgre.CompanyRepository
 	logoRepository    postgre.LogoRepository
+	historyRepository postgre.CompanyHistoryRepository
+	tagRepository     postgre.TagRepository
+	changeRepository  postgre.CompanyChangeRepository
+	transactor        postgre.Transactor
+	members           *Member
 	cache             *cache.LocalCache
 	producer          producer.Company
+	storage           storage.Storage
+
+	duplicateThreshold float64
+	logoSizes          []int
+	logoVersions       int
+	logoMaxBytes       int64
+	logoMaxDimension   int
+	logoMaxPixels      int
 }
 
 // NewCompany creates new Company service
 func NewCompany(
 	companyRepository postgre.CompanyRepository, logoRepository postgre.LogoRepository,
-	localCache *cache.LocalCache, redisProducer producer.Company) *Company {
+	historyRepository postgre.CompanyHistoryRepository, tagRepository postgre.TagRepository,
+	changeRepository postgre.CompanyChangeRepository, transactor postgre.Transactor, members *Member,
+	localCache *cache.LocalCache, redisProducer producer.Company, blobStorage storage.Storage, cfg *config.CompanyConfig) *Company {
 	return &Company{
-		companyRepository: companyRepository, logoRepository: logoRepository, cache: localCache, producer: redisProducer}
+		companyRepository: companyRepository, logoRepository: logoRepository, historyRepository: historyRepository,
+		tagRepository: tagRepository, changeRepository: changeRepository, transactor: transactor, members: members,
+		cache: localCache, producer: redisProducer, storage: blobStorage, duplicateThreshold: cfg.DuplicateThreshold,
+		logoSizes: cfg.LogoSizes, logoVersions: cfg.LogoVersions, logoMaxBytes: cfg.LogoMaxBytes,
+		logoMaxDimension: cfg.LogoMaxDimension, logoMaxPixels: cfg.LogoMaxPixels}
+}
+
+// GetAll return page of companies matching filter
+func (c *Company) GetAll(ctx context.Context, filter *model.CompanyFilter) (*model.CompanyPage, error) {
+	return c.companyRepository.GetAll(ctx, filter)
+}
+
+// Export streams all companies matching filter to fn
+func (c *Company) Export(ctx context.Context, filter *model.CompanyFilter, fn func(company *model.Company) error) error {
+	return c.companyRepository.Export(ctx, filter, fn)
 }
 
-// GetAll return all companies
-func (c *Company) GetAll(ctx context.Context) ([]*model.Company, error) {
-	return c.companyRepository.GetAll(ctx)
+// Search finds companies by partial or misspelled name
+func (c *Company) Search(ctx context.Context, query string, limit int) ([]*model.CompanySearchResult, error) {
+	return c.companyRepository.Search(ctx, query, limit)
 }
 
-// GetByID Retrieves company based on given ID
+// GetByID Retrieves company based on given ID, returns *model.MovedError for ids of merged companies
 func (c *Company) GetByID(ctx context.Context, id uuid.UUID) (*model.Company, error) {
 	company, err := c.cache.Read(id)
 	if err != nil {
@@ -55,110 +80,372 @@ func (c *Company) GetByID(ctx context.Context, id uuid.UUID) (*model.Company, er
 		return company, nil
 	}
 	company, err = c.companyRepository.GetOne(ctx, id)
-	if company != nil {
-		redisErr := c.producer.Produce(ctx, company.ID, event.UPDATE, company.Name)
-		if redisErr != nil {
-			log.Error(err)
+	if errors.Is(err, echo.ErrNotFound) {
+		targetID, aliasErr := c.companyRepository.ResolveAlias(ctx, id)
+		if aliasErr != nil {
+			return nil, aliasErr
 		}
+		return nil, &model.MovedError{ID: targetID}
 	}
+	if err != nil {
+		return nil, err
+	}
+	c.produce(ctx, event.CACHE, company)
+	return company, nil
+}
 
-	return company, err
+// GetDeleted return page of deleted companies matching filter
+func (c *Company) GetDeleted(ctx context.Context, filter *model.CompanyFilter) (*model.CompanyPage, error) {
+	filter.Deleted = true
+	return c.companyRepository.GetAll(ctx, filter)
+}
+
+// Create  company owned by user, unless force is set fails with *model.DuplicateError
+// if companies with similar names exist
+func (c *Company) Create(ctx context.Context, userID uuid.UUID, company *model.Company, force bool) (uuid.UUID, error) {
+	company.NormalizedName = model.NormalizeCompanyName(company.Name)
+	if !force {
+		err := c.checkDuplicates(ctx, company)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	if company.ParentID != nil {
+		err := c.CheckParent(ctx, userID, uuid.Nil, *company.ParentID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	company.CreatedBy = userID
+	var id uuid.UUID
+	err := c.inTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = c.companyRepository.Create(ctx, company)
+		if err != nil {
+			return err
+		}
+		err = c.members.AddOwner(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		err = c.record(ctx, userID, id, model.HistoryCreate, nil, companySnapshot(company))
+		if err != nil {
+			return err
+		}
+		c.produceSaved(ctx, company)
+		return nil
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
 }
 
-// Create  company
-func (c *Company) Create(ctx context.Context, company *model.Company) (uuid.UUID, error) {
-	return c.companyRepository.Create(ctx, company)
+// Import creates batch of companies owned by user in one transaction, so either all of them are created or none.
+// Parents of companies must be checked by CheckParent and names by CheckDuplicates unless import is forced
+func (c *Company) Import(ctx context.Context, userID uuid.UUID, companies []*model.Company) error {
+	for _, company := range companies {
+		company.CreatedBy = userID
+		company.NormalizedName = model.NormalizeCompanyName(company.Name)
+	}
+	return c.inTx(ctx, func(ctx context.Context) error {
+		err := c.companyRepository.CreateBatch(ctx, companies)
+		if err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, len(companies))
+		entries := make([]*model.CompanyHistory, len(companies))
+		for i, company := range companies {
+			ids[i] = company.ID
+			entries[i] = &model.CompanyHistory{
+				CompanyID: company.ID,
+				UserID:    userID,
+				Action:    model.HistoryCreate,
+				After:     companySnapshot(company),
+			}
+		}
+		err = c.members.AddOwners(ctx, ids, userID)
+		if err != nil {
+			return err
+		}
+		err = c.historyRepository.CreateBatch(ctx, entries)
+		if err != nil {
+			return err
+		}
+		for _, company := range companies {
+			c.produceSaved(ctx, company)
+		}
+		return nil
+	})
 }
 
-// Update update company
-func (c *Company) Update(ctx context.Context, company *model.Company) error {
-	return c.companyRepository.Update(ctx, company)
+// Update update company, company.Version 0 skips concurrent modification check,
+// force skips check of similar names
+func (c *Company) Update(ctx context.Context, userID uuid.UUID, company *model.Company, force bool) error {
+	err := c.members.Authorize(ctx, userID, company.ID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	return c.inTx(ctx, func(ctx context.Context) error {
+		before, err := c.companyRepository.GetForUpdate(ctx, company.ID)
+		if err != nil {
+			return err
+		}
+		return c.update(ctx, userID, before, company, force)
+	})
 }
 
-// Delete delete company
-func (c *Company) Delete(ctx context.Context, id uuid.UUID) error {
-	company, err := c.cache.Read(id)
+// Patch applies modification to the stored state of company and saves the result,
+// version 0 skips concurrent modification check, force skips check of similar names
+func (c *Company) Patch(ctx context.Context, userID, id uuid.UUID, version int64, force bool,
+	apply func(company *model.Company) (*model.Company, error)) (*model.Company, error) {
+	err := c.members.Authorize(ctx, userID, id, model.RoleEditor)
 	if err != nil {
-		log.Info(err)
+		return nil, err
 	}
-	if company != nil {
-		c.cache.Delete(id)
+	var patched *model.Company
+	err = c.inTx(ctx, func(ctx context.Context) error {
+		company, err := c.companyRepository.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != company.Version {
+			return model.ErrVersionConflict
+		}
+
+		patched, err = apply(company)
+		if err != nil {
+			return err
+		}
+		patched.ID = company.ID
+		patched.Version = company.Version
+		return c.update(ctx, userID, company, patched, force)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return c.companyRepository.Delete(ctx, id)
+	return patched, nil
 }
 
-// AddLogo add logo to a company( fails if company already has a logo)
-func (c *Company) AddLogo(ctx context.Context, companyID string, file *multipart.FileHeader) error {
-	id, err := uuid.Parse(companyID)
+// Delete moves company to trash, version 0 skips concurrent modification check
+func (c *Company) Delete(ctx context.Context, userID, id uuid.UUID, version int64) error {
+	err := c.members.Authorize(ctx, userID, id, model.RoleOwner)
 	if err != nil {
 		return err
 	}
-	logo, err := c.logoRepository.GetByCompanyID(ctx, id)
+	return c.inTx(ctx, func(ctx context.Context) error {
+		before, err := c.companyRepository.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		err = c.companyRepository.Delete(ctx, id, version)
+		if err != nil {
+			return err
+		}
+		err = c.record(ctx, userID, id, model.HistoryDelete, companySnapshot(before), nil)
+		if err != nil {
+			return err
+		}
+		c.produce(ctx, event.DELETE, before)
+		return nil
+	})
+}
+
+// Restore brings company back from trash
+func (c *Company) Restore(ctx context.Context, userID, id uuid.UUID) error {
+	err := c.members.Authorize(ctx, userID, id, model.RoleOwner)
 	if err != nil {
 		return err
 	}
-	if logo != nil {
-		return fmt.Errorf(companyAlreadyHasALogoErr)
+	return c.inTx(ctx, func(ctx context.Context) error {
+		err := c.companyRepository.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+		after, err := c.companyRepository.GetOne(ctx, id)
+		if err != nil {
+			return err
+		}
+		if after.ParentID != nil {
+			// hierarchies formed before deleted parents were checked can make restored company its own ancestor
+			err = c.companyRepository.LockHierarchy(ctx)
+			if err != nil {
+				return err
+			}
+			cycle, cycleErr := c.companyRepository.IsAncestor(ctx, id, id)
+			if cycleErr != nil {
+				return cycleErr
+			}
+			if cycle {
+				return model.ErrHierarchyCycle
+			}
+		}
+		err = c.record(ctx, userID, id, model.HistoryRestore, nil, companySnapshot(after))
+		if err != nil {
+			return err
+		}
+		c.produce(ctx, event.UPDATE, after)
+		return nil
+	})
+}
+
+// update saves company changed from before state, it must be called in transaction holding lock of company
+func (c *Company) update(ctx context.Context, userID uuid.UUID, before, company *model.Company, force bool) error {
+	company.NormalizedName = model.NormalizeCompanyName(company.Name)
+	if !force && company.NormalizedName != model.NormalizeCompanyName(before.Name) {
+		err := c.checkDuplicates(ctx, company)
+		if err != nil {
+			return err
+		}
+	}
+	parentChanged := !sameParent(before.ParentID, company.ParentID)
+	if parentChanged && company.ParentID != nil {
+		err := c.companyRepository.LockHierarchy(ctx)
+		if err != nil {
+			return err
+		}
+		err = c.CheckParent(ctx, userID, company.ID, *company.ParentID)
+		if err != nil {
+			return err
+		}
 	}
-	imageURI := c.buildFileURI(companyID)
-	err = c.saveFile(imageURI, file)
+	err := c.companyRepository.Update(ctx, company)
 	if err != nil {
-		return fmt.Errorf(fileSaveError)
+		return err
 	}
-
-	err = c.logoRepository.Create(ctx, id, imageURI)
+	err = c.record(ctx, userID, company.ID, model.HistoryUpdate, companySnapshot(before), companySnapshot(company))
 	if err != nil {
 		return err
 	}
+	if parentChanged {
+		c.produce(ctx, event.HIERARCHY, company)
+		return nil
+	}
+	c.produce(ctx, event.UPDATE, company)
 	return nil
 }
 
-// GetLogo Get company logo
-func (c *Company) GetLogo(ctx context.Context, companyID uuid.UUID) (string, error) {
-	logo, err := c.logoRepository.GetByCompanyID(ctx, companyID)
+// CheckDuplicates returns *model.DuplicateError if existing companies have names similar to name of new company
+func (c *Company) CheckDuplicates(ctx context.Context, company *model.Company) error {
+	company.NormalizedName = model.NormalizeCompanyName(company.Name)
+	return c.checkDuplicates(ctx, company)
+}
+
+// checkDuplicates returns *model.DuplicateError if other companies have names similar to company name
+func (c *Company) checkDuplicates(ctx context.Context, company *model.Company) error {
+	if company.NormalizedName == "" {
+		return nil
+	}
+	matches, err := c.companyRepository.FindSimilar(ctx, company.NormalizedName, company.ID, c.duplicateThreshold,
+		duplicateCandidatesLimit)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if len(matches) > 0 {
+		return &model.DuplicateError{Candidates: matches}
 	}
-	return logo.Image, nil
+	return nil
 }
 
-func (c *Company) buildFileURI(companyID string) string {
-	wd, _ := os.Getwd()
-	basepath := filepath.Join(wd, "data", "company")
-	_ = os.MkdirAll(basepath, os.ModePerm)
-	fileURI := filepath.Join(basepath, companyID)
-	return fmt.Sprintf("%s%s", fileURI, imageExt)
+type pendingEventsKey struct{}
+
+// pendingEvent company event waiting for commit of transaction
+type pendingEvent struct {
+	action  string
+	company *model.Company
 }
 
-func (c *Company) saveFile(fileName string, file *multipart.FileHeader) error {
-	src, err := file.Open()
-	if err != nil {
+type pendingEvents []pendingEvent
+
+// inTx runs fn in transaction and publishes events produced by fn after commit. Nested calls run in savepoints
+// of the enclosing transaction, their events are appended to the feed and published by the outermost call
+// or by the batch
+func (c *Company) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents); ok {
+		produced := len(*pending)
+		err := c.transactor.InTx(ctx, fn)
+		if err != nil {
+			*pending = (*pending)[:produced]
+		}
 		return err
 	}
-	defer func() {
-		if srcError := src.Close(); srcError != nil {
-			log.Printf("Error closing file: %s\n", srcError)
+	var events pendingEvents
+	err := c.transactor.InTx(ctx, func(ctx context.Context) error {
+		err := fn(context.WithValue(ctx, pendingEventsKey{}, &events))
+		if err != nil {
+			return err
 		}
-	}()
-
-	dst, err := os.Create(filepath.Clean(fileName))
+		return c.appendChanges(ctx, events)
+	})
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if dstError := dst.Close(); dstError != nil {
-			log.Printf("Error closing file: %s\n", dstError)
-		}
-	}()
+	c.publish(ctx, events)
+	return nil
+}
 
-	if _, err = io.Copy(dst, src); err != nil {
-		return err
+// produce queues company event until commit of transaction started by inTx, the event is appended to the changes
+// feed right before commit, so the feed never misses a committed change. Events produced outside of transaction,
+// like cache events, aren't part of the feed and are published immediately
+func (c *Company) produce(ctx context.Context, action string, company *model.Company) {
+	pending, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents)
+	if !ok {
+		c.publish(ctx, pendingEvents{{action: action, company: company}})
+		return
 	}
+	*pending = append(*pending, pendingEvent{action: action, company: company})
+}
+
+// produceSaved produces event of created company, hierarchy event if it has parent
+func (c *Company) produceSaved(ctx context.Context, company *model.Company) {
+	if company.ParentID != nil {
+		c.produce(ctx, event.HIERARCHY, company)
+		return
+	}
+	c.produce(ctx, event.UPDATE, company)
+}
 
-	err = dst.Sync()
+// publish evicts deleted companies from local cache and sends events of committed changes, failure is only logged
+// because the changes are already stored. Eviction waits for commit as well, otherwise concurrent read could cache
+// the company again before its deletion becomes visible
+func (c *Company) publish(ctx context.Context, events pendingEvents) {
+	for _, pending := range events {
+		if pending.action == event.DELETE {
+			c.cache.Delete(pending.company)
+		}
+		err := c.producer.Produce(ctx, pending.action, pending.company)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// PurgeDeleted permanently removes companies deleted before given time together with their logos,
+// images are removed from storage after the records are deleted
+func (c *Company) PurgeDeleted(ctx context.Context, deletedBefore time.Time) error {
+	var ids []uuid.UUID
+	var images []string
+	err := c.transactor.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		ids, err = c.companyRepository.Purge(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			deleted, logoErr := c.logoRepository.DeleteByCompanyID(ctx, id)
+			if logoErr != nil {
+				return logoErr
+			}
+			images = append(images, deleted...)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-
+	c.removeLogoFiles(ctx, images)
+	if len(ids) > 0 {
+		log.Infof("purged %d deleted companies", len(ids))
+	}
 	return nil
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/repository/postgre"
+)
+
+// errBatchFailed rolls back transaction of atomic batch after failure of its operation
+var errBatchFailed = errors.New("batch operation failed")
+
+// CompanyBatch service executing company mutations in one transaction
+type CompanyBatch struct {
+	companies  *Company
+	transactor postgre.Transactor
+}
+
+// NewCompanyBatch creates new company batch service
+func NewCompanyBatch(companies *Company, transactor postgre.Transactor) *CompanyBatch {
+	return &CompanyBatch{companies: companies, transactor: transactor}
+}
+
+// Execute runs operations of user in one transaction and reports whether it has been committed.
+// Atomic batch is rolled back by the first failed operation and the rest of operations are skipped,
+// otherwise only failed operations are rolled back. Changes of executed operations are appended to the feed
+// right before commit and their events are published after commit
+func (b *CompanyBatch) Execute(ctx context.Context, userID uuid.UUID, operations []*model.CompanyOperation,
+	atomic bool) ([]*model.CompanyOperationResult, bool, error) {
+	results := make([]*model.CompanyOperationResult, len(operations))
+	for i := range results {
+		results[i] = new(model.CompanyOperationResult)
+	}
+
+	var committed pendingEvents
+	err := b.transactor.InTx(ctx, func(ctx context.Context) error {
+		for i, operation := range operations {
+			var events pendingEvents
+			result := results[i]
+			result.Executed = true
+			if atomic {
+				result.Err = b.execute(context.WithValue(ctx, pendingEventsKey{}, &events), userID, operation, result)
+				if result.Err != nil {
+					return errBatchFailed
+				}
+			} else {
+				result.Err = b.transactor.InTx(ctx, func(ctx context.Context) error {
+					return b.execute(context.WithValue(ctx, pendingEventsKey{}, &events), userID, operation, result)
+				})
+				if result.Err != nil {
+					continue
+				}
+			}
+			committed = append(committed, events...)
+		}
+		return b.companies.appendChanges(ctx, committed)
+	})
+	if errors.Is(err, errBatchFailed) {
+		return results, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	b.companies.publish(ctx, committed)
+	return results, true, nil
+}
+
+// execute runs single operation and fills its result
+func (b *CompanyBatch) execute(ctx context.Context, userID uuid.UUID, operation *model.CompanyOperation,
+	result *model.CompanyOperationResult) error {
+	switch operation.Action {
+	case model.OperationCreate:
+		id, err := b.companies.Create(ctx, userID, operation.Company, operation.Force)
+		if err != nil {
+			return err
+		}
+		result.ID = id
+		result.Version = operation.Company.Version
+	case model.OperationUpdate:
+		operation.Company.ID = operation.ID
+		operation.Company.Version = operation.Version
+		err := b.companies.Update(ctx, userID, operation.Company, operation.Force)
+		if err != nil {
+			return err
+		}
+		result.ID = operation.ID
+		result.Version = operation.Company.Version
+	case model.OperationDelete:
+		err := b.companies.Delete(ctx, userID, operation.ID, operation.Version)
+		if err != nil {
+			return err
+		}
+		result.ID = operation.ID
+	default:
+		return fmt.Errorf("unknown batch operation %q", operation.Action)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/event"
+	"entetry/gotest/internal/model"
+)
+
+// GetChanges returns page of companies changes published after change with sequence number since,
+// 0 starts from the oldest retained change. Fails with model.ErrChangesExpired if change since has been purged
+func (c *Company) GetChanges(ctx context.Context, since int64, limit int) (*model.CompanyChangePage, error) {
+	if since > 0 {
+		exists, err := c.changeRepository.Exists(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, model.ErrChangesExpired
+		}
+	}
+	changes, err := c.changeRepository.GetSince(ctx, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.CompanyChangePage{Items: changes, NextCursor: strconv.FormatInt(since, 10)}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.HasMore = true
+	}
+	if len(page.Items) > 0 {
+		page.NextCursor = strconv.FormatInt(page.Items[len(page.Items)-1].Seq, 10)
+	}
+	return page, nil
+}
+
+// GetLatestChanges returns empty page with cursor of the latest change, so that the feed can be followed
+// after full synchronization of companies
+func (c *Company) GetLatestChanges(ctx context.Context) (*model.CompanyChangePage, error) {
+	seq, err := c.changeRepository.LastSeq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CompanyChangePage{NextCursor: strconv.FormatInt(seq, 10)}, nil
+}
+
+// PurgeChanges removes changes feed entries published before given time
+func (c *Company) PurgeChanges(ctx context.Context, changedBefore time.Time) error {
+	purged, err := c.changeRepository.Purge(ctx, changedBefore)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Infof("purged %d company changes", purged)
+	}
+	return nil
+}
+
+// appendChanges appends events of transaction to the changes feed, cache events are skipped. It must be the last
+// statement of the transaction because the feed lock is held until commit
+func (c *Company) appendChanges(ctx context.Context, events pendingEvents) error {
+	changes := make([]*model.CompanyChange, 0, len(events))
+	for _, pending := range events {
+		change := &model.CompanyChange{CompanyID: pending.company.ID}
+		switch pending.action {
+		case event.UPDATE, event.HIERARCHY:
+			change.Type = model.ChangeUpsert
+			change.Company = pending.company
+		case event.DELETE:
+			change.Type = model.ChangeDelete
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return c.changeRepository.CreateBatch(ctx, changes)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"entetry/gotest/internal/model"
+)
+
+// GetAncestors returns parents chain of company, nearest parent first
+func (c *Company) GetAncestors(ctx context.Context, id uuid.UUID) ([]*model.Company, error) {
+	_, err := c.companyRepository.GetOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.companyRepository.GetAncestors(ctx, id)
+}
+
+// GetSubsidiaries returns company with tree of its subsidiaries up to given depth
+func (c *Company) GetSubsidiaries(ctx context.Context, id uuid.UUID, depth int) (*model.CompanyTree, error) {
+	company, err := c.companyRepository.GetOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subsidiaries, err := c.companyRepository.GetSubsidiaries(ctx, id, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &model.CompanyTree{Company: *company}
+	nodes := map[uuid.UUID]*model.CompanyTree{id: root}
+	// subsidiaries are ordered by depth, so parent node is always created before its children
+	for _, subsidiary := range subsidiaries {
+		parent, ok := nodes[*subsidiary.ParentID]
+		if !ok {
+			continue
+		}
+		node := &model.CompanyTree{Company: *subsidiary}
+		parent.Subsidiaries = append(parent.Subsidiaries, node)
+		nodes[subsidiary.ID] = node
+	}
+	return root, nil
+}
+
+// CheckParent checks that parent exists, user may edit it and company isn't parent's ancestor, even through
+// deleted companies which can be restored. companyID is uuid.Nil for new companies, parent of existing company
+// must be checked and changed in transaction holding the hierarchy lock
+func (c *Company) CheckParent(ctx context.Context, userID, companyID, parentID uuid.UUID) error {
+	if parentID == companyID {
+		return model.ErrHierarchyCycle
+	}
+	_, err := c.companyRepository.GetOne(ctx, parentID)
+	if errors.Is(err, echo.ErrNotFound) {
+		return model.ErrParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	err = c.members.Authorize(ctx, userID, parentID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if companyID == uuid.Nil {
+		return nil
+	}
+	cycle, err := c.companyRepository.IsAncestor(ctx, parentID, companyID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return model.ErrHierarchyCycle
+	}
+	return nil
+}
+
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
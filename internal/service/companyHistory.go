@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/model"
+)
+
+// GetHistory returns page of company changes with field diffs, newest first
+func (c *Company) GetHistory(ctx context.Context, companyID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CompanyHistoryPage, error) {
+	page, err := c.historyRepository.GetByCompanyID(ctx, companyID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range page.Items {
+		entry.Changes = diffSnapshots(entry.Before, entry.After)
+	}
+	return page, nil
+}
+
+// record saves company change in history, it must be called in transaction of the change
+// so that the change isn't stored without its history entry
+func (c *Company) record(ctx context.Context, userID, companyID uuid.UUID, action string,
+	before, after map[string]interface{}) error {
+	return c.historyRepository.Create(ctx, &model.CompanyHistory{
+		CompanyID: companyID,
+		UserID:    userID,
+		Action:    action,
+		Before:    before,
+		After:     after,
+	})
+}
+
+// companySnapshot converts company into history snapshot
+func companySnapshot(company *model.Company) map[string]interface{} {
+	if company == nil {
+		return nil
+	}
+	data, err := json.Marshal(company)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+	var snapshot map[string]interface{}
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+	delete(snapshot, "Version")
+	delete(snapshot, "CreatedAt")
+	delete(snapshot, "UpdatedAt")
+	return snapshot
+}
+
+// diffSnapshots returns fields which values differ in snapshots
+func diffSnapshots(before, after map[string]interface{}) []model.FieldChange {
+	var changes []model.FieldChange
+	diffFields("", before, after, &changes)
+	return changes
+}
+
+func diffFields(prefix string, before, after map[string]interface{}, changes *[]model.FieldChange) {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+		beforeValue, afterValue := before[key], after[key]
+		beforeMap, beforeIsMap := beforeValue.(map[string]interface{})
+		afterMap, afterIsMap := afterValue.(map[string]interface{})
+		if (beforeIsMap || beforeValue == nil) && (afterIsMap || afterValue == nil) && (beforeIsMap || afterIsMap) {
+			diffFields(field, beforeMap, afterMap, changes)
+			continue
+		}
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			*changes = append(*changes, model.FieldChange{Field: field, Before: beforeValue, After: afterValue})
+		}
+	}
+}
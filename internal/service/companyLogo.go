@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"entetry/gotest/internal/imaging"
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/storage"
+)
+
+const fileSaveError = "file save error"
+
+// removeLogoFiles removes stored images of deleted logos, failures are only logged
+func (c *Company) removeLogoFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := c.storage.Delete(ctx, key); err != nil {
+			log.Errorf("cannot remove logo %s: %v", key, err)
+		}
+	}
+}
+
+// AddLogo add logo to a company, fails with model.ErrLogoExists if company already has a logo
+func (c *Company) AddLogo(ctx context.Context, userID, companyID uuid.UUID, file *multipart.FileHeader) error {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	_, err = c.uploadLogo(ctx, userID, companyID, file, false)
+	return err
+}
+
+// ReplaceLogo uploads new current version of company logo, the previous one is kept as an earlier version
+func (c *Company) ReplaceLogo(ctx context.Context, userID, companyID uuid.UUID, file *multipart.FileHeader) (*model.Logo, error) {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	return c.uploadLogo(ctx, userID, companyID, file, true)
+}
+
+// DeleteLogo leaves company without logo, its versions are kept so that it can be reverted
+func (c *Company) DeleteLogo(ctx context.Context, userID, companyID uuid.UUID) error {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	err = c.inTx(ctx, func(ctx context.Context) error {
+		logo, err := c.logoRepository.ClearCurrent(ctx, companyID)
+		if err != nil {
+			return err
+		}
+		return c.record(ctx, userID, companyID, model.HistoryDeleteLogo, map[string]interface{}{"Logo": logo.Image},
+			map[string]interface{}{"Logo": nil})
+	})
+	if err != nil {
+		return err
+	}
+	c.pruneLogos(ctx, companyID)
+	return nil
+}
+
+// GetLogoVersions returns kept versions of company logo, newest first
+func (c *Company) GetLogoVersions(ctx context.Context, userID, companyID uuid.UUID) ([]*model.Logo, error) {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return c.logoRepository.GetVersions(ctx, companyID)
+}
+
+// RevertLogo makes earlier version of company logo current
+func (c *Company) RevertLogo(ctx context.Context, userID, companyID uuid.UUID, version int) (*model.Logo, error) {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	var logo *model.Logo
+	err = c.inTx(ctx, func(ctx context.Context) error {
+		current, err := c.lockLogo(ctx, companyID)
+		if err != nil {
+			return err
+		}
+		logo, err = c.logoRepository.SetCurrent(ctx, companyID, version)
+		if err != nil {
+			return err
+		}
+		return c.record(ctx, userID, companyID, model.HistoryRevertLogo, logoSnapshot(current), logoSnapshot(logo))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logo, nil
+}
+
+// lockLogo locks company until the end of transaction and returns its current logo, nil if company has no logo
+func (c *Company) lockLogo(ctx context.Context, companyID uuid.UUID) (*model.Logo, error) {
+	_, err := c.companyRepository.GetForUpdate(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return c.logoRepository.GetByCompanyID(ctx, companyID)
+}
+
+// uploadLogo stores uploaded file as new current logo version, unless replace is set company must have no logo
+func (c *Company) uploadLogo(ctx context.Context, userID, companyID uuid.UUID, file *multipart.FileHeader,
+	replace bool) (*model.Logo, error) {
+	content, contentType, err := c.readLogo(file)
+	if err != nil {
+		return nil, err
+	}
+	logo, err := c.storeLogo(ctx, companyID, content, contentType)
+	if err != nil {
+		return nil, err
+	}
+	logo.CreatedBy = &userID
+
+	err = c.inTx(ctx, func(ctx context.Context) error {
+		current, err := c.lockLogo(ctx, companyID)
+		if err != nil {
+			return err
+		}
+		if current != nil && !replace {
+			return model.ErrLogoExists
+		}
+		err = c.logoRepository.Create(ctx, logo)
+		if err != nil {
+			return err
+		}
+		action := model.HistoryAddLogo
+		if current != nil {
+			action = model.HistoryReplaceLogo
+		}
+		return c.record(ctx, userID, companyID, action, logoSnapshot(current), logoSnapshot(logo))
+	})
+	if err != nil {
+		c.removeLogoFiles(ctx, logoImages(logo))
+		return nil, err
+	}
+	c.pruneLogos(ctx, companyID)
+	return logo, nil
+}
+
+// pruneLogos removes logo versions exceeding configured number of kept versions, the current logo counts as one
+// of them only if company has it, so deleted logo stays revertible. Failures are only logged
+func (c *Company) pruneLogos(ctx context.Context, companyID uuid.UUID) {
+	images, err := c.logoRepository.Prune(ctx, companyID, c.logoVersions)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	c.removeLogoFiles(ctx, images)
+}
+
+// storeLogo puts logo and its downscaled variants into storage, already stored images are removed on failure
+func (c *Company) storeLogo(ctx context.Context, companyID uuid.UUID, content []byte, contentType string) (*model.Logo, error) {
+	thumbnails, err := imaging.Thumbnails(content, contentType, c.logoSizes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrUnsupportedMediaType, err)
+	}
+	logo := &model.Logo{
+		ID:          uuid.New(),
+		CompanyID:   companyID,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+	}
+	logo.Image = logoKey(logo, 0, contentType)
+	for _, thumbnail := range thumbnails {
+		logo.Variants = append(logo.Variants, &model.LogoVariant{
+			Dimension:   thumbnail.Dimension,
+			Width:       thumbnail.Width,
+			Height:      thumbnail.Height,
+			Image:       logoKey(logo, thumbnail.Dimension, thumbnail.ContentType),
+			ContentType: thumbnail.ContentType,
+			Size:        int64(len(thumbnail.Data)),
+		})
+	}
+
+	err = c.storage.Put(ctx, logo.Image, bytes.NewReader(content), logo.Size, contentType)
+	for i := 0; err == nil && i < len(thumbnails); i++ {
+		variant := logo.Variants[i]
+		err = c.storage.Put(ctx, variant.Image, bytes.NewReader(thumbnails[i].Data), variant.Size, variant.ContentType)
+	}
+	if err != nil {
+		log.Error(err)
+		c.removeLogoFiles(ctx, logoImages(logo))
+		return nil, fmt.Errorf(fileSaveError)
+	}
+	return logo, nil
+}
+
+// GetLogo returns content of company logo variant best suited for displaying in size x size pixels:
+// the smallest variant not smaller than size or the original when there is no such variant or size is 0.
+// Content type is the stored one, caller must close the reader
+func (c *Company) GetLogo(ctx context.Context, companyID uuid.UUID, size int) (io.ReadCloser, *storage.ObjectInfo, error) {
+	logo, err := c.logoRepository.GetByCompanyID(ctx, companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if logo == nil {
+		return nil, nil, echo.ErrNotFound
+	}
+	image, contentType := logo.Image, logo.ContentType
+	if size > 0 {
+		variants, variantsErr := c.logoRepository.GetVariants(ctx, logo.ID)
+		if variantsErr != nil {
+			return nil, nil, variantsErr
+		}
+		if variant := logoVariant(variants, size); variant != nil {
+			image, contentType = variant.Image, variant.ContentType
+		}
+	}
+	reader, info, err := c.storage.Get(ctx, image)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Errorf("logo %s of company %s is missing in storage", image, companyID)
+		return nil, nil, echo.ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info.ContentType = contentType
+	return reader, info, nil
+}
+
+// logoVariant returns the smallest of variants ordered by dimension which is at least size,
+// nil if all of them are smaller and the original image must be used
+func logoVariant(variants []*model.LogoVariant, size int) *model.LogoVariant {
+	for _, variant := range variants {
+		if variant.Dimension >= size {
+			return variant
+		}
+	}
+	return nil
+}
+
+// logoSnapshot logo state recorded in company history
+func logoSnapshot(logo *model.Logo) map[string]interface{} {
+	if logo == nil {
+		return map[string]interface{}{"Logo": nil}
+	}
+	return map[string]interface{}{"Logo": logo.Image, "LogoVersion": logo.Version}
+}
+
+// logoImages storage keys of logo and its variants
+func logoImages(logo *model.Logo) []string {
+	images := []string{logo.Image}
+	for _, variant := range logo.Variants {
+		images = append(images, variant.Image)
+	}
+	return images
+}
+
+// logoKey storage key of logo version image, dimension is 0 for the original image
+func logoKey(logo *model.Logo, dimension int, contentType string) string {
+	if dimension == 0 {
+		return fmt.Sprintf("company/%s/%s%s", logo.CompanyID, logo.ID, imaging.Extension(contentType))
+	}
+	return fmt.Sprintf("company/%s/%s-%dpx%s", logo.CompanyID, logo.ID, dimension, imaging.Extension(contentType))
+}
+
+// readLogo reads uploaded logo and detects its type from content. Files over size limit, non-images and images
+// exceeding dimension limits are rejected before being decoded, svg images are sanitized
+func (c *Company) readLogo(file *multipart.FileHeader) ([]byte, string, error) {
+	if file.Size > c.logoMaxBytes {
+		return nil, "", model.ErrFileTooLarge
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		if srcError := src.Close(); srcError != nil {
+			log.Printf("Error closing file: %s\n", srcError)
+		}
+	}()
+
+	content, err := io.ReadAll(io.LimitReader(src, c.logoMaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(content)) > c.logoMaxBytes {
+		return nil, "", model.ErrFileTooLarge
+	}
+	contentType := imaging.ContentType(content)
+	switch contentType {
+	case "":
+		return nil, "", model.ErrUnsupportedMediaType
+	case imaging.SVG:
+		content, err = imaging.SanitizeSVG(content)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", model.ErrUnsupportedMediaType, err)
+		}
+	}
+	err = imaging.CheckDimensions(content, contentType, c.logoMaxDimension, c.logoMaxPixels)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return nil, "", fmt.Errorf("%w: maximum is %dx%d and %d pixels", model.ErrImageTooLarge,
+			c.logoMaxDimension, c.logoMaxDimension, c.logoMaxPixels)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", model.ErrUnsupportedMediaType, err)
+	}
+	return content, contentType, nil
+}
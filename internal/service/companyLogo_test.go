@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"entetry/gotest/internal/model"
+)
+
+func TestLogoVariant(t *testing.T) {
+	variants := []*model.LogoVariant{{Dimension: 64}, {Dimension: 128}, {Dimension: 256}}
+	tests := []struct {
+		size int
+		want int
+	}{
+		{size: 1, want: 64},
+		{size: 64, want: 64},
+		{size: 65, want: 128},
+		{size: 200, want: 256},
+		{size: 256, want: 256},
+		{size: 257, want: 0},
+	}
+	for _, test := range tests {
+		got := 0
+		if variant := logoVariant(variants, test.size); variant != nil {
+			got = variant.Dimension
+		}
+		if got != test.want {
+			t.Errorf("size %d: variant %d, want %d", test.size, got, test.want)
+		}
+	}
+	if variant := logoVariant(nil, 64); variant != nil {
+		t.Errorf("logo without variants: variant %d, want original", variant.Dimension)
+	}
+}
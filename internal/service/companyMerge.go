@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/event"
+	"entetry/gotest/internal/model"
+)
+
+// Merge folds source company into target one, requires owner role in both companies
+func (c *Company) Merge(ctx context.Context, userID, targetID, sourceID uuid.UUID) (*model.Company, error) {
+	for _, id := range []uuid.UUID{targetID, sourceID} {
+		err := c.members.Authorize(ctx, userID, id, model.RoleOwner)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var merge *model.CompanyMerge
+	var merged *model.Company
+	err := c.inTx(ctx, func(ctx context.Context) error {
+		source, target, err := c.lockMerged(ctx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		// subsidiaries of source become subsidiaries of target, so target can't be one of them
+		err = c.companyRepository.LockHierarchy(ctx)
+		if err != nil {
+			return err
+		}
+		cycle, err := c.companyRepository.IsAncestor(ctx, targetID, sourceID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return model.ErrHierarchyCycle
+		}
+
+		merge, err = c.companyRepository.Merge(ctx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		merged, err = c.companyRepository.GetOne(ctx, targetID)
+		if err != nil {
+			return err
+		}
+
+		after := companySnapshot(merged)
+		if after != nil {
+			after["MergedFrom"] = sourceID.String()
+		}
+		err = c.record(ctx, userID, targetID, model.HistoryMerge, companySnapshot(target), after)
+		if err != nil {
+			return err
+		}
+		err = c.record(ctx, userID, sourceID, model.HistoryMerge, companySnapshot(source),
+			map[string]interface{}{"MergedInto": targetID.String()})
+		if err != nil {
+			return err
+		}
+
+		c.produce(ctx, event.DELETE, source)
+		c.produce(ctx, event.UPDATE, merged)
+		for _, subsidiary := range merge.Subsidiaries {
+			c.produce(ctx, event.HIERARCHY, subsidiary)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// target keeps logo versions of both companies up to the limit
+	c.pruneLogos(ctx, targetID)
+	return merged, nil
+}
+
+// lockMerged locks source and target companies in order of their ids, so that concurrent merges of the same
+// companies in opposite directions don't deadlock
+func (c *Company) lockMerged(ctx context.Context, sourceID, targetID uuid.UUID) (source, target *model.Company,
+	err error) {
+	if bytes.Compare(sourceID[:], targetID[:]) < 0 {
+		if source, err = c.companyRepository.GetForUpdate(ctx, sourceID); err != nil {
+			return nil, nil, err
+		}
+		target, err = c.companyRepository.GetForUpdate(ctx, targetID)
+		return source, target, err
+	}
+	if target, err = c.companyRepository.GetForUpdate(ctx, targetID); err != nil {
+		return nil, nil, err
+	}
+	source, err = c.companyRepository.GetForUpdate(ctx, sourceID)
+	return source, target, err
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/event"
+	"entetry/gotest/internal/model"
+)
+
+// AddTags labels company by tags, requires editor role
+func (c *Company) AddTags(ctx context.Context, userID, companyID uuid.UUID, names []string) (*model.Company, error) {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tags := model.NormalizeTags(names)
+	if len(tags) == 0 {
+		return c.companyRepository.GetOne(ctx, companyID)
+	}
+	return c.changeTags(ctx, userID, companyID, func(ctx context.Context) error {
+		return c.tagRepository.AddToCompany(ctx, companyID, tags)
+	})
+}
+
+// RemoveTag removes tag from company, requires editor role
+func (c *Company) RemoveTag(ctx context.Context, userID, companyID uuid.UUID, name string) (*model.Company, error) {
+	err := c.members.Authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	return c.changeTags(ctx, userID, companyID, func(ctx context.Context) error {
+		return c.tagRepository.RemoveFromCompany(ctx, companyID, model.NormalizeTag(name))
+	})
+}
+
+// AutocompleteTags returns tags starting with prefix, most used first
+func (c *Company) AutocompleteTags(ctx context.Context, prefix string, limit int) ([]*model.Tag, error) {
+	return c.tagRepository.Autocomplete(ctx, model.NormalizeTag(prefix), limit)
+}
+
+// changeTags changes tags of locked company by change, records the change and publishes updated company
+func (c *Company) changeTags(ctx context.Context, userID, companyID uuid.UUID,
+	change func(ctx context.Context) error) (*model.Company, error) {
+	var after *model.Company
+	err := c.inTx(ctx, func(ctx context.Context) error {
+		before, err := c.companyRepository.GetForUpdate(ctx, companyID)
+		if err != nil {
+			return err
+		}
+		err = change(ctx)
+		if err != nil {
+			return err
+		}
+		after, err = c.companyRepository.GetOne(ctx, companyID)
+		if err != nil {
+			return err
+		}
+		err = c.record(ctx, userID, companyID, model.HistoryUpdate, companySnapshot(before), companySnapshot(after))
+		if err != nil {
+			return err
+		}
+		c.produce(ctx, event.UPDATE, after)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/repository/postgre"
+)
+
+// Contact company contacts service struct
+type Contact struct {
+	contactRepository postgre.ContactRepository
+	companyRepository postgre.CompanyRepository
+	members           *Member
+}
+
+// NewContact creates new company contacts service
+func NewContact(contactRepository postgre.ContactRepository, companyRepository postgre.CompanyRepository,
+	members *Member) *Contact {
+	return &Contact{contactRepository: contactRepository, companyRepository: companyRepository, members: members}
+}
+
+// GetAll returns page of contacts of not deleted company, requires viewer role
+func (c *Contact) GetAll(ctx context.Context, userID, companyID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.ContactPage, error) {
+	err := c.authorize(ctx, userID, companyID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return c.contactRepository.GetByCompanyID(ctx, companyID, cursor, limit)
+}
+
+// GetByID returns contact of not deleted company, requires viewer role
+func (c *Contact) GetByID(ctx context.Context, userID, companyID, id uuid.UUID) (*model.Contact, error) {
+	err := c.authorize(ctx, userID, companyID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return c.contactRepository.GetOne(ctx, companyID, id)
+}
+
+// Create adds contact to not deleted company, requires editor role
+func (c *Contact) Create(ctx context.Context, userID uuid.UUID, contact *model.Contact) error {
+	err := c.authorize(ctx, userID, contact.CompanyID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	return c.contactRepository.Create(ctx, contact)
+}
+
+// Update updates contact of not deleted company, requires editor role
+func (c *Contact) Update(ctx context.Context, userID uuid.UUID, contact *model.Contact) error {
+	err := c.authorize(ctx, userID, contact.CompanyID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	return c.contactRepository.Update(ctx, contact)
+}
+
+// Delete removes contact of not deleted company, requires editor role
+func (c *Contact) Delete(ctx context.Context, userID, companyID, id uuid.UUID) error {
+	err := c.authorize(ctx, userID, companyID, model.RoleEditor)
+	if err != nil {
+		return err
+	}
+	return c.contactRepository.Delete(ctx, companyID, id)
+}
+
+// authorize checks user role in company, contacts of deleted companies are hidden until restore
+func (c *Contact) authorize(ctx context.Context, userID, companyID uuid.UUID, role string) error {
+	err := c.members.Authorize(ctx, userID, companyID, role)
+	if err != nil {
+		return err
+	}
+	_, err = c.companyRepository.GetOne(ctx, companyID)
+	return err
+}
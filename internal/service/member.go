@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/config"
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/repository/postgre"
+)
+
+const (
+	viewerRank = iota + 1
+	editorRank
+	ownerRank
+)
+
+// Member company membership service struct
+type Member struct {
+	memberRepository postgre.CompanyMemberRepository
+	transactor       postgre.Transactor
+	adminUserIDs     []string
+}
+
+// NewMember creates new company membership service
+func NewMember(memberRepository postgre.CompanyMemberRepository, transactor postgre.Transactor,
+	cfg *config.CompanyConfig) *Member {
+	return &Member{
+		memberRepository: memberRepository,
+		transactor:       transactor,
+		adminUserIDs:     cfg.AdminUserIDs}
+}
+
+// Authorize checks that user has at least given role in company, administrators pass any check.
+// Companies without owners are managed by administrators, who can make any user the owner with Grant
+func (m *Member) Authorize(ctx context.Context, userID, companyID uuid.UUID, role string) error {
+	if m.isAdmin(userID) {
+		return nil
+	}
+	granted, err := m.memberRepository.GetRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if roleRank(granted) < roleRank(role) {
+		return model.ErrForbidden
+	}
+	return nil
+}
+
+// AddOwner makes user the owner of company
+func (m *Member) AddOwner(ctx context.Context, companyID, userID uuid.UUID) error {
+	return m.memberRepository.Upsert(ctx, &model.CompanyMember{
+		CompanyID: companyID,
+		UserID:    userID,
+		Role:      model.RoleOwner,
+		GrantedBy: userID,
+	})
+}
+
+// AddOwners grants owner role in each of new companies to their creator
+func (m *Member) AddOwners(ctx context.Context, companyIDs []uuid.UUID, userID uuid.UUID) error {
+	members := make([]*model.CompanyMember, len(companyIDs))
+	for i, companyID := range companyIDs {
+		members[i] = &model.CompanyMember{CompanyID: companyID, UserID: userID, Role: model.RoleOwner, GrantedBy: userID}
+	}
+	return m.memberRepository.CreateBatch(ctx, members)
+}
+
+// GetMembers returns members of company, requires viewer role
+func (m *Member) GetMembers(ctx context.Context, actorID, companyID uuid.UUID) ([]*model.CompanyMember, error) {
+	err := m.Authorize(ctx, actorID, companyID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return m.memberRepository.GetByCompanyID(ctx, companyID)
+}
+
+// Grant grants role in company to user, requires owner role
+func (m *Member) Grant(ctx context.Context, actorID, companyID, userID uuid.UUID, role string) (*model.CompanyMember, error) {
+	err := m.Authorize(ctx, actorID, companyID, model.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	member := &model.CompanyMember{
+		CompanyID: companyID,
+		UserID:    userID,
+		Role:      role,
+		GrantedBy: actorID,
+	}
+	err = m.transactor.InTx(ctx, func(ctx context.Context) error {
+		if role != model.RoleOwner {
+			err := m.checkNotLastOwner(ctx, companyID, userID)
+			if err != nil {
+				return err
+			}
+		}
+		return m.memberRepository.Upsert(ctx, member)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// Revoke removes user from company members, requires owner role unless user leaves company himself
+func (m *Member) Revoke(ctx context.Context, actorID, companyID, userID uuid.UUID) error {
+	if actorID != userID {
+		err := m.Authorize(ctx, actorID, companyID, model.RoleOwner)
+		if err != nil {
+			return err
+		}
+	}
+	return m.transactor.InTx(ctx, func(ctx context.Context) error {
+		err := m.checkNotLastOwner(ctx, companyID, userID)
+		if err != nil {
+			return err
+		}
+		return m.memberRepository.Delete(ctx, companyID, userID)
+	})
+}
+
+// checkNotLastOwner fails if user is the only owner of company, it must be called in transaction
+// of the membership change because owners stay locked until its end
+func (m *Member) checkNotLastOwner(ctx context.Context, companyID, userID uuid.UUID) error {
+	count, err := m.memberRepository.CountOwnersForUpdate(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	role, err := m.memberRepository.GetRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if role != model.RoleOwner {
+		return nil
+	}
+	if count <= 1 {
+		return model.ErrLastOwner
+	}
+	return nil
+}
+
+func (m *Member) isAdmin(userID uuid.UUID) bool {
+	for _, id := range m.adminUserIDs {
+		if id == userID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func roleRank(role string) int {
+	switch role {
+	case model.RoleOwner:
+		return ownerRank
+	case model.RoleEditor:
+		return editorRank
+	case model.RoleViewer:
+		return viewerRank
+	default:
+		return 0
+	}
+}
@@ -38,3 +38,8 @@ func (u *User) Create(ctx context.Context, username, password, email string) (uu
 
 	return u.userRepository.Create(ctx, username, string(pwdHash), email)
 }
+
+// GetByUsernames returns existing users with given usernames
+func (u *User) GetByUsernames(ctx context.Context, usernames []string) ([]*model.User, error) {
+	return u.userRepository.GetByUsernames(ctx, usernames)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"entetry/gotest/internal/event"
+	"entetry/gotest/internal/model"
+	"entetry/gotest/internal/repository/postgre"
+)
+
+// Watchlist followed companies and their change notifications service struct
+type Watchlist struct {
+	watchlistRepository    postgre.WatchlistRepository
+	notificationRepository postgre.NotificationRepository
+	companyRepository      postgre.CompanyRepository
+	members                *Member
+}
+
+// NewWatchlist creates new watchlist service
+func NewWatchlist(watchlistRepository postgre.WatchlistRepository, notificationRepository postgre.NotificationRepository,
+	companyRepository postgre.CompanyRepository, members *Member) *Watchlist {
+	return &Watchlist{
+		watchlistRepository:    watchlistRepository,
+		notificationRepository: notificationRepository,
+		companyRepository:      companyRepository,
+		members:                members,
+	}
+}
+
+// Watch adds not deleted company to user watchlist, requires viewer role
+func (w *Watchlist) Watch(ctx context.Context, userID, companyID uuid.UUID) error {
+	err := w.members.Authorize(ctx, userID, companyID, model.RoleViewer)
+	if err != nil {
+		return err
+	}
+	if _, err = w.companyRepository.GetOne(ctx, companyID); err != nil {
+		return err
+	}
+	return w.watchlistRepository.Add(ctx, userID, companyID)
+}
+
+// Unwatch removes company from user watchlist
+func (w *Watchlist) Unwatch(ctx context.Context, userID, companyID uuid.UUID) error {
+	return w.watchlistRepository.Remove(ctx, userID, companyID)
+}
+
+// GetCompanies returns page of companies watched by user
+func (w *Watchlist) GetCompanies(ctx context.Context, userID uuid.UUID, cursor *model.Cursor,
+	limit int) (*model.CompanyPage, error) {
+	return w.watchlistRepository.GetCompanies(ctx, userID, cursor, limit)
+}
+
+// GetNotifications returns page of user notifications, newest first
+func (w *Watchlist) GetNotifications(ctx context.Context, userID uuid.UUID, unread bool, cursor *model.Cursor,
+	limit int) (*model.NotificationPage, error) {
+	return w.notificationRepository.GetByUserID(ctx, userID, unread, cursor, limit)
+}
+
+// MarkRead marks user notification as read
+func (w *Watchlist) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	return w.notificationRepository.MarkRead(ctx, userID, id)
+}
+
+// MarkAllRead marks all user notifications as read and returns number of marked ones
+func (w *Watchlist) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return w.notificationRepository.MarkAllRead(ctx, userID)
+}
+
+// Notify creates notification of company stream event for each watcher still having access to the company.
+// Cache events don't change companies and are skipped
+func (w *Watchlist) Notify(ctx context.Context, action string, company *model.Company) error {
+	switch action {
+	case event.UPDATE, event.HIERARCHY, event.DELETE:
+	default:
+		return nil
+	}
+	watchers, err := w.watchlistRepository.GetWatchers(ctx, company.ID)
+	if err != nil {
+		return err
+	}
+
+	var recipients []uuid.UUID
+	for _, userID := range watchers {
+		err = w.members.Authorize(ctx, userID, company.ID, model.RoleViewer)
+		if errors.Is(err, model.ErrForbidden) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, userID)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	return w.notificationRepository.CreateBatch(ctx, &model.Notification{
+		CompanyID:   company.ID,
+		CompanyName: company.Name,
+		Event:       action,
+	}, recipients)
+}
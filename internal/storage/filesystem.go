@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// Filesystem storage keeping objects as files under root directory
+type Filesystem struct {
+	root string
+}
+
+// NewFilesystem creates filesystem storage, relative root is resolved against working directory
+func NewFilesystem(root string) (*Filesystem, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve storage root: %v", err)
+	}
+	err = os.MkdirAll(abs, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create storage root: %v", err)
+	}
+	return &Filesystem{root: abs}, nil
+}
+
+// Put writes object into temporary file and renames it, so readers never see partial content
+func (f *Filesystem) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	path := f.path(key)
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return fmt.Errorf("cannot create directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("cannot create file: %v", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot write file: %v", err)
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("cannot write file: %v", err)
+	}
+	return nil
+}
+
+// Get opens object file, content type is derived from key extension
+func (f *Filesystem) Get(_ context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open file: %v", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("cannot stat file: %v", err)
+	}
+	return file, &ObjectInfo{
+		Size:        stat.Size(),
+		ContentType: contentTypeOrDefault(mime.TypeByExtension(filepath.Ext(key))),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+// Delete removes object file
+func (f *Filesystem) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(f.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove file: %v", err)
+	}
+	return nil
+}
+
+func (f *Filesystem) path(key string) string {
+	return filepath.Join(f.root, filepath.FromSlash(key))
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Memory in-memory storage for tests and local runs, content is lost on restart
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// NewMemory creates empty in-memory storage
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string]memoryObject)}
+}
+
+// Put stores copy of object content
+func (m *Memory) Put(_ context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cannot read object: %v", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{data: data, contentType: contentTypeOrDefault(contentType), modTime: time.Now()}
+	return nil
+}
+
+// Get returns reader of object content
+func (m *Memory) Get(_ context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	object, ok := m.objects[key]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), &ObjectInfo{
+		Size:        int64(len(object.data)),
+		ContentType: object.contentType,
+		ModTime:     object.modTime,
+	}, nil
+}
+
+// Delete removes object
+func (m *Memory) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
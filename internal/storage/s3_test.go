@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-west-1"
+	testBucket    = "logos"
+)
+
+var authorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=` + testAccessKey +
+	`/(\d{8})/` + testRegion + `/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=([0-9a-f]{64})$`)
+
+// fakeS3 minimal S3 server storing objects of one bucket in memory and verifying request signatures
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	// status overrides response status of every request when set
+	status int
+	// paths escaped request paths in order of requests
+	paths []string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3) {
+	fake := &fakeS3{t: t, objects: make(map[string][]byte), types: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	s, err := NewS3(server.URL, testBucket, testRegion, testAccessKey, testSecretKey)
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+	return fake, s
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, r.URL.EscapedPath())
+	if err := verifySignature(r); err != nil {
+		f.t.Errorf("%s %s: %v", r.Method, r.URL, err)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		_, _ = io.WriteString(w, "<Error><Code>InternalError</Code></Error>")
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/"+testBucket+"/")
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+		f.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verifySignature checks signature v4 of request independently of the signing code
+func verifySignature(r *http.Request) error {
+	match := authorizationPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return fmt.Errorf("malformed Authorization header %q", r.Header.Get("Authorization"))
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if _, err := time.Parse(s3DateFormat, amzDate); err != nil || amzDate[:8] != match[1] {
+		return fmt.Errorf("X-Amz-Date %q doesn't match credential date %s", amzDate, match[1])
+	}
+	if r.Header.Get("X-Amz-Content-Sha256") != "UNSIGNED-PAYLOAD" {
+		return fmt.Errorf("X-Amz-Content-Sha256 = %q, want UNSIGNED-PAYLOAD", r.Header.Get("X-Amz-Content-Sha256"))
+	}
+
+	canonical := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" + "x-amz-content-sha256:UNSIGNED-PAYLOAD\n" + "x-amz-date:" + amzDate + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\nUNSIGNED-PAYLOAD"
+	hash := sha256.Sum256([]byte(canonical))
+	scope := match[1] + "/" + testRegion + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{match[1], testRegion, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	if signature := hex.EncodeToString(hmacSHA256(key, stringToSign)); signature != match[2] {
+		return fmt.Errorf("signature = %s, want %s", match[2], signature)
+	}
+	return nil
+}
+
+func TestS3(t *testing.T) {
+	_, s := newFakeS3(t)
+	testStorage(t, s)
+}
+
+func TestS3EscapesKey(t *testing.T) {
+	fake, s := newFakeS3(t)
+	ctx := context.Background()
+	const key = "company/a b+c/логотип.png"
+	if err := s.Put(ctx, key, strings.NewReader("x"), 1, "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	want := "/logos/company/a%20b%2Bc/%D0%BB%D0%BE%D0%B3%D0%BE%D1%82%D0%B8%D0%BF.png"
+	if fake.paths[0] != want {
+		t.Errorf("request path = %s, want %s", fake.paths[0], want)
+	}
+	reader, _, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = reader.Close()
+}
+
+func TestS3DefaultContentType(t *testing.T) {
+	fake, s := newFakeS3(t)
+	if err := s.Put(context.Background(), "blob", bytes.NewReader(nil), 0, ""); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if fake.types["blob"] != defaultContentType {
+		t.Errorf("Content-Type = %q, want %q", fake.types["blob"], defaultContentType)
+	}
+}
+
+func TestS3Errors(t *testing.T) {
+	fake, s := newFakeS3(t)
+	fake.status = http.StatusInternalServerError
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "blob", strings.NewReader("x"), 1, ""); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Put() error = %v, want unexpected status 500", err)
+	}
+	if _, _, err := s.Get(ctx, "blob"); err == nil || !strings.Contains(err.Error(), "InternalError") {
+		t.Errorf("Get() error = %v, want error with response body", err)
+	}
+	if err := s.Delete(ctx, "blob"); err == nil {
+		t.Error("Delete() error = nil, want unexpected status error")
+	}
+
+	fake.status = http.StatusNotFound
+	if err := s.Delete(ctx, "blob"); err != nil {
+		t.Errorf("Delete() of missing object error = %v, want nil", err)
+	}
+}
+
+func TestNewS3(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		bucket   string
+	}{
+		{endpoint: "", bucket: testBucket},
+		{endpoint: "s3.amazonaws.com", bucket: testBucket},
+		{endpoint: "https://s3.amazonaws.com", bucket: ""},
+	}
+	for _, test := range tests {
+		if _, err := NewS3(test.endpoint, test.bucket, testRegion, testAccessKey, testSecretKey); err == nil {
+			t.Errorf("NewS3(%q, %q) error = nil, want error", test.endpoint, test.bucket)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Package storage contains blob storage drivers addressed by object keys
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"entetry/gotest/internal/config"
+)
+
+const defaultContentType = "application/octet-stream"
+
+var (
+	// ErrNotFound object with given key doesn't exist
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey key is empty, absolute or escapes storage root
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage blob storage interface, keys are slash separated relative paths like "company/<id>.jpeg"
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns object content, caller must close the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes object, deleting missing object isn't an error
+	Delete(ctx context.Context, key string) error
+}
+
+// ObjectInfo stored object metadata
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// New creates storage driver selected by config
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "filesystem":
+		return NewFilesystem(cfg.Root)
+	case "memory":
+		return NewMemory(), nil
+	case "s3":
+		return NewS3(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// validateKey rejects keys which could address objects outside of the storage root
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+func contentTypeOrDefault(contentType string) string {
+	if contentType == "" {
+		return defaultContentType
+	}
+	return contentType
+}
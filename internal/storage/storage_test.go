@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "company/1.png", valid: true},
+		{key: "logo.svg", valid: true},
+		{key: "company/a b/1.png", valid: true},
+		{key: "", valid: false},
+		{key: "/company/1.png", valid: false},
+		{key: "company/../1.png", valid: false},
+		{key: "../1.png", valid: false},
+		{key: "company/./1.png", valid: false},
+		{key: "company//1.png", valid: false},
+		{key: "company/", valid: false},
+		{key: `company\1.png`, valid: false},
+		{key: "..", valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			err := validateKey(test.key)
+			if test.valid && err != nil {
+				t.Errorf("validateKey(%q) error = %v, want nil", test.key, err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("validateKey(%q) error = %v, want %v", test.key, err, ErrInvalidKey)
+			}
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStorage(t, NewMemory())
+}
+
+func TestFilesystem(t *testing.T) {
+	fs, err := NewFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystem() error = %v", err)
+	}
+	testStorage(t, fs)
+}
+
+// testStorage checks behavior common to all storage drivers
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+	const key = "company/1/logo.png"
+	content := []byte("\x89PNG content")
+
+	if _, _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() of missing object error = %v, want %v", err, ErrNotFound)
+	}
+	if err := s.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reader, info, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		t.Fatalf("read error = %v", err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("Get() content = %q, want %q", data, content)
+	}
+	if info.Size != int64(len(content)) || info.ContentType != "image/png" || info.ModTime.IsZero() {
+		t.Errorf("Get() info = %+v, want size %d, image/png and modification time", info, len(content))
+	}
+
+	if err = s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err = s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of deleted object error = %v, want %v", err, ErrNotFound)
+	}
+	if err = s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of missing object error = %v, want nil", err)
+	}
+
+	for _, invalid := range []string{"", "/etc/passwd", "../escape.png"} {
+		if err = s.Put(ctx, invalid, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want %v", invalid, err, ErrInvalidKey)
+		}
+		if _, _, err = s.Get(ctx, invalid); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q) error = %v, want %v", invalid, err, ErrInvalidKey)
+		}
+		if err = s.Delete(ctx, invalid); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Delete(%q) error = %v, want %v", invalid, err, ErrInvalidKey)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package xlsx contains streaming writer of single sheet xlsx workbooks
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+		`Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" ` +
+		`Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd = `</sheetData></worksheet>`
+)
+
+// Writer writes rows of a single sheet directly to the underlying writer,
+// cells are written as inline strings and numbers so memory usage doesn't depend on rows count
+type Writer struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+}
+
+// NewWriter writes workbook parts preceding sheet data and creates Writer, sheetName must be at most 31 characters
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, escape(sheetName))},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(file)
+	if _, err = sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+	return &Writer{archive: archive, sheet: sheet}, nil
+}
+
+// WriteRow appends row to the sheet, supported cell types are string and integers
+func (w *Writer) WriteRow(cells ...interface{}) error {
+	if _, err := w.sheet.WriteString("<row>"); err != nil {
+		return err
+	}
+	for _, cell := range cells {
+		var err error
+		switch value := cell.(type) {
+		case int:
+			_, err = w.sheet.WriteString(`<c t="n"><v>` + strconv.Itoa(value) + `</v></c>`)
+		case int64:
+			_, err = w.sheet.WriteString(`<c t="n"><v>` + strconv.FormatInt(value, 10) + `</v></c>`)
+		case string:
+			_, err = w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escape(value) + `</t></is></c>`)
+		default:
+			return fmt.Errorf("unsupported cell type %T", cell)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+// Close finishes sheet and workbook, it doesn't close the underlying writer
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
+
+// escape escapes xml special characters and replaces characters not allowed in xml
+func escape(text string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
@@ -0,0 +1,128 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type sheetXML struct {
+	Rows []struct {
+		Cells []struct {
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	rows := [][]interface{}{
+		{"name", "headcount", "revenue"},
+		{`<Acme & "Sons">`, 42, int64(-9007199254740993)},
+		{"  spaced  ", 0, int64(0)},
+		{"line\nbreak\ttab", -1, int64(1)},
+		{"unicode: Zürich 東京 🚀", 1 << 30, int64(1) << 62},
+		{"", 7, int64(7)},
+		{"control\x01char", 1, int64(1)},
+	}
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, `Companies <&>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err = writer.WriteRow(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string][]byte)
+	for _, file := range archive.File {
+		reader, openErr := file.Open()
+		if openErr != nil {
+			t.Fatal(openErr)
+		}
+		content, readErr := io.ReadAll(reader)
+		_ = reader.Close()
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		parts[file.Name] = content
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels",
+		"xl/workbook.xml", "xl/worksheets/sheet1.xml"} {
+		content, ok := parts[name]
+		if !ok {
+			t.Fatalf("part %s is missing", name)
+		}
+		var root struct{}
+		if err = xml.Unmarshal(content, &root); err != nil {
+			t.Errorf("part %s isn't well-formed: %v", name, err)
+		}
+	}
+
+	var book struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = xml.Unmarshal(parts["xl/workbook.xml"], &book); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Sheets) != 1 || book.Sheets[0].Name != `Companies <&>` {
+		t.Errorf("sheets = %+v", book.Sheets)
+	}
+
+	var sheet sheetXML
+	if err = xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	var got [][]interface{}
+	for _, row := range sheet.Rows {
+		var cells []interface{}
+		for _, cell := range row.Cells {
+			switch cell.Type {
+			case "inlineStr":
+				cells = append(cells, cell.Inline)
+			case "n":
+				cells = append(cells, cell.Value)
+			default:
+				t.Fatalf("unexpected cell type %q", cell.Type)
+			}
+		}
+		got = append(got, cells)
+	}
+	want := [][]interface{}{
+		{"name", "headcount", "revenue"},
+		{`<Acme & "Sons">`, "42", "-9007199254740993"},
+		{"  spaced  ", "0", "0"},
+		{"line\nbreak\ttab", "-1", "1"},
+		{"unicode: Zürich 東京 🚀", "1073741824", "4611686018427387904"},
+		{"", "7", "7"},
+		{"control\uFFFDchar", "1", "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+func TestWriterUnsupportedCell(t *testing.T) {
+	writer, err := NewWriter(io.Discard, "Sheet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.WriteRow("name", 1.5); err == nil {
+		t.Error("float cell is accepted")
+	}
+}
@@ -12,7 +12,6 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/go-redis/redis/v9"
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
@@ -25,9 +24,11 @@ import (
 	"entetry/gotest/internal/event"
 	"entetry/gotest/internal/handlers"
 	"entetry/gotest/internal/middleware"
+	"entetry/gotest/internal/model"
 	"entetry/gotest/internal/producer"
 	"entetry/gotest/internal/repository/postgre"
 	"entetry/gotest/internal/service"
+	"entetry/gotest/internal/storage"
 )
 
 // @title          Gotest Swagger API
@@ -48,6 +49,18 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	companyCfg, err := config.NewCompanyConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	storageCfg, err := config.NewStorageConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	blobStorage, err := storage.New(storageCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	sigChan := make(chan os.Signal, 1)
@@ -81,10 +94,33 @@ func main() {
 
 	companyRepository := postgre.NewCompanyRepository(db)
 	logoRepository := postgre.NewLogoRepository(db)
-	companyService := service.NewCompany(companyRepository, logoRepository, cacheCompany, redisProducer)
-	companyHandler := handlers.NewCompany(companyService)
+	companyHistoryRepository := postgre.NewCompanyHistoryRepository(db)
+	companyMemberRepository := postgre.NewCompanyMemberRepository(db)
+	tagRepository := postgre.NewTagRepository(db)
+	contactRepository := postgre.NewContactRepository(db)
+	commentRepository := postgre.NewCommentRepository(db)
+	watchlistRepository := postgre.NewWatchlistRepository(db)
+	notificationRepository := postgre.NewNotificationRepository(db)
+	companyChangeRepository := postgre.NewCompanyChangeRepository(db)
+	transactor := postgre.NewTransactor(db)
+	memberService := service.NewMember(companyMemberRepository, transactor, companyCfg)
+	memberHandler := handlers.NewMember(memberService)
+	companyService := service.NewCompany(companyRepository, logoRepository, companyHistoryRepository, tagRepository,
+		companyChangeRepository, transactor, memberService, cacheCompany, redisProducer, blobStorage, companyCfg)
+	companyHandler := handlers.NewCompany(companyService, companyCfg)
+	batchService := service.NewCompanyBatch(companyService, transactor)
+	batchHandler := handlers.NewCompanyBatch(batchService, companyCfg)
+	contactService := service.NewContact(contactRepository, companyRepository, memberService)
+	contactHandler := handlers.NewContact(contactService)
+	commentService := service.NewComment(commentRepository, companyRepository, userService, memberService)
+	commentHandler := handlers.NewComment(commentService)
+	watchlistService := service.NewWatchlist(watchlistRepository, notificationRepository, companyRepository,
+		memberService)
+	watchlistHandler := handlers.NewWatchlist(watchlistService)
 
 	go consumeCompanies(redisClient, cacheCompany)
+	go notifyWatchers(redisClient, watchlistService)
+	go purgeCompanies(ctx, companyService, companyCfg)
 
 	e := echo.New()
 
@@ -98,12 +134,56 @@ func main() {
 	company := e.Group("api/company")
 	company.Use(middleware.NewJwtMiddleware(jwtCfg.AccessTokenKey))
 	company.POST("", companyHandler.Create)
+	company.POST("/import", companyHandler.Import)
+	company.POST("/batch", batchHandler.Execute)
 	company.GET("", companyHandler.GetAll)
+	company.GET("/search", companyHandler.Search)
+	company.GET("/export", companyHandler.Export)
+	company.GET("/changes", companyHandler.GetChanges)
+	company.GET("/tags", companyHandler.AutocompleteTags)
+	company.GET("/trash", companyHandler.GetDeleted, middleware.NewAdminMiddleware(companyCfg.AdminUserIDs))
 	company.GET("/:id", companyHandler.GetByID)
 	company.PUT("", companyHandler.Update)
+	company.PATCH("/:id", companyHandler.Patch)
 	company.DELETE("/:id", companyHandler.Delete)
+	company.POST("/:id/restore", companyHandler.Restore)
+	company.POST("/:id/merge", companyHandler.Merge)
+	company.GET("/:id/history", companyHandler.GetHistory)
+	company.GET("/:id/ancestors", companyHandler.GetAncestors)
+	company.GET("/:id/subsidiaries", companyHandler.GetSubsidiaries)
+	company.POST("/:id/tags", companyHandler.AddTags)
+	company.DELETE("/:id/tags/:tag", companyHandler.RemoveTag)
+	company.GET("/:id/members", memberHandler.GetAll)
+	company.PUT("/:id/members/:userId", memberHandler.Grant)
+	company.DELETE("/:id/members/:userId", memberHandler.Revoke)
+	company.GET("/:id/contacts", contactHandler.GetAll)
+	company.POST("/:id/contacts", contactHandler.Create)
+	company.GET("/:id/contacts/:contactId", contactHandler.GetByID)
+	company.PUT("/:id/contacts/:contactId", contactHandler.Update)
+	company.DELETE("/:id/contacts/:contactId", contactHandler.Delete)
+	company.GET("/:id/comments", commentHandler.GetAll)
+	company.POST("/:id/comments", commentHandler.Create)
+	company.GET("/:id/comments/:commentId/replies", commentHandler.GetReplies)
+	company.PUT("/:id/comments/:commentId", commentHandler.Update)
+	company.DELETE("/:id/comments/:commentId", commentHandler.Delete)
 	company.POST("/logo", companyHandler.AddLogo)
 	company.GET("/logo/:id", companyHandler.GetLogoByCompanyID)
+	company.PUT("/logo/:id", companyHandler.ReplaceLogo)
+	company.DELETE("/logo/:id", companyHandler.DeleteLogo)
+	company.GET("/logo/:id/versions", companyHandler.GetLogoVersions)
+	company.POST("/logo/:id/versions/:version/revert", companyHandler.RevertLogo)
+
+	watchlist := e.Group("api/watchlist")
+	watchlist.Use(middleware.NewJwtMiddleware(jwtCfg.AccessTokenKey))
+	watchlist.GET("", watchlistHandler.GetAll)
+	watchlist.PUT("/:id", watchlistHandler.Watch)
+	watchlist.DELETE("/:id", watchlistHandler.Unwatch)
+
+	notifications := e.Group("api/notifications")
+	notifications.Use(middleware.NewJwtMiddleware(jwtCfg.AccessTokenKey))
+	notifications.GET("", watchlistHandler.GetNotifications)
+	notifications.POST("/read", watchlistHandler.MarkAllRead)
+	notifications.POST("/:id/read", watchlistHandler.MarkRead)
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
@@ -132,18 +212,52 @@ func main() {
 
 func consumeCompanies(redisClient *redis.Client, localCache *cache.LocalCache) {
 	redisCompanyConsumer := consumer.NewRedisCompanyConsumer(redisClient, fmt.Sprintf("%d000-0", time.Now().Unix()))
-	go redisCompanyConsumer.Consume(context.Background(), func(id uuid.UUID, action, name string) {
+	go redisCompanyConsumer.Consume(context.Background(), func(action string, company *model.Company) error {
 		switch action {
-		case event.UPDATE:
-			localCache.Update(id, name)
+		case event.UPDATE, event.HIERARCHY, event.CACHE:
+			localCache.Update(company)
 		case event.DELETE:
-			localCache.Delete(id)
+			localCache.Delete(company)
 		default:
-			log.Error("Unknown event")
+			return fmt.Errorf("unknown event %q", action)
 		}
+		return nil
 	})
 }
 
+// notifyWatchers turns company events into notifications, instances share consumer group
+// so that every event is handled once
+func notifyWatchers(redisClient *redis.Client, watchlistService *service.Watchlist) {
+	name, err := os.Hostname()
+	if err != nil {
+		log.Fatal(err)
+	}
+	notificationConsumer := consumer.NewRedisCompanyGroupConsumer(redisClient, "notifications", name)
+	go notificationConsumer.Consume(context.Background(), func(action string, company *model.Company) error {
+		return watchlistService.Notify(context.Background(), action, company)
+	})
+}
+
+func purgeCompanies(ctx context.Context, companyService *service.Company, cfg *config.CompanyConfig) {
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		err := companyService.PurgeDeleted(ctx, time.Now().Add(-cfg.Retention))
+		if err != nil {
+			log.Error(err)
+		}
+		err = companyService.PurgeChanges(ctx, time.Now().Add(-cfg.ChangesRetention))
+		if err != nil {
+			log.Error(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func buildRedis(cfg *config.Config) *redis.Client {
 	opts := &redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort),
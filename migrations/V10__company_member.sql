@@ -0,0 +1,14 @@
+ALTER TABLE company
+    ADD COLUMN created_by uuid;
+
+CREATE TABLE company_member
+(
+    company_id uuid        NOT NULL REFERENCES company (id) ON DELETE CASCADE,
+    user_id    uuid        NOT NULL,
+    role       varchar(16) NOT NULL,
+    granted_by uuid        NOT NULL,
+    granted_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (company_id, user_id)
+);
+
+CREATE INDEX company_member_user_id_idx ON company_member (user_id);
@@ -0,0 +1,4 @@
+ALTER TABLE company
+    ADD COLUMN parent_id uuid REFERENCES company (id) ON DELETE SET NULL;
+
+CREATE INDEX company_parent_id_idx ON company (parent_id);
@@ -0,0 +1,16 @@
+CREATE TABLE tag
+(
+    id   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX tag_name_pattern_idx ON tag (name varchar_pattern_ops);
+
+CREATE TABLE company_tag
+(
+    company_id uuid NOT NULL REFERENCES company (id) ON DELETE CASCADE,
+    tag_id     uuid NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+    PRIMARY KEY (company_id, tag_id)
+);
+
+CREATE INDEX company_tag_tag_id_idx ON company_tag (tag_id);
@@ -0,0 +1,8 @@
+CREATE TABLE company_alias
+(
+    alias_id   uuid PRIMARY KEY,
+    company_id uuid        NOT NULL REFERENCES company (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX company_alias_company_id_idx ON company_alias (company_id);
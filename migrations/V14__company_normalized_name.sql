@@ -0,0 +1,11 @@
+ALTER TABLE company
+    ADD COLUMN normalized_name varchar NOT NULL DEFAULT '';
+
+-- approximation of model.NormalizeCompanyName for existing companies
+UPDATE company
+SET normalized_name = regexp_replace(
+        trim(regexp_replace(lower(regexp_replace(name, '[.,''’]', '', 'g')), '[^[:alnum:]]+', ' ', 'g')),
+        '(.)( (inc|incorporated|corp|corporation|co|company|llc|llp|lp|ltd|limited|plc|pty|gmbh|ag|kg|sa|sas|sarl|srl|spa|bv|nv|oy|ab|as|ooo))+$',
+        '\1');
+
+CREATE INDEX company_normalized_name_trgm_idx ON company USING gin (normalized_name gin_trgm_ops);
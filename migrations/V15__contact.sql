@@ -0,0 +1,12 @@
+CREATE TABLE contact
+(
+    id         uuid PRIMARY KEY,
+    company_id uuid         NOT NULL REFERENCES company (id) ON DELETE CASCADE,
+    name       varchar(256) NOT NULL,
+    role       varchar(128) NOT NULL DEFAULT '',
+    email      varchar(320) NOT NULL DEFAULT '',
+    phone      varchar(16)  NOT NULL DEFAULT '',
+    created_at timestamptz  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX contact_company_id_idx ON contact (company_id, name, id);
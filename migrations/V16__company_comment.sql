@@ -0,0 +1,14 @@
+CREATE TABLE company_comment
+(
+    id         uuid PRIMARY KEY,
+    company_id uuid        NOT NULL REFERENCES company (id) ON DELETE CASCADE,
+    parent_id  uuid REFERENCES company_comment (id) ON DELETE CASCADE,
+    author_id  uuid        NOT NULL,
+    body       text        NOT NULL,
+    mentions   uuid[]      NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    edited_at  timestamptz,
+    deleted_at timestamptz
+);
+
+CREATE INDEX company_comment_thread_idx ON company_comment (company_id, parent_id, created_at, id);
@@ -0,0 +1,22 @@
+CREATE TABLE company_watch
+(
+    user_id    uuid        NOT NULL,
+    company_id uuid        NOT NULL REFERENCES company (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, company_id)
+);
+
+CREATE INDEX company_watch_company_id_idx ON company_watch (company_id);
+
+CREATE TABLE notification
+(
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      uuid         NOT NULL,
+    company_id   uuid         NOT NULL,
+    company_name varchar(256) NOT NULL,
+    event        varchar(16)  NOT NULL,
+    created_at   timestamptz  NOT NULL DEFAULT now(),
+    read_at      timestamptz
+);
+
+CREATE INDEX notification_user_id_idx ON notification (user_id, created_at DESC, id DESC);
@@ -0,0 +1,16 @@
+ALTER TABLE company
+    ADD COLUMN created_at timestamptz,
+    ADD COLUMN updated_at timestamptz;
+
+UPDATE company
+SET created_at = coalesce((SELECT min(changed_at) FROM company_history WHERE company_id = company.id), now()),
+    updated_at = coalesce((SELECT max(changed_at) FROM company_history WHERE company_id = company.id), now());
+
+ALTER TABLE company
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT now();
+
+CREATE INDEX company_created_at_idx ON company (created_at);
+CREATE INDEX company_updated_at_idx ON company (updated_at);
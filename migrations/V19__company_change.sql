@@ -0,0 +1,10 @@
+CREATE TABLE company_change
+(
+    seq        bigserial PRIMARY KEY,
+    type       varchar(16) NOT NULL,
+    company_id uuid        NOT NULL,
+    company    jsonb,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX company_change_changed_at_idx ON company_change (changed_at);
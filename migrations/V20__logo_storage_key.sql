@@ -0,0 +1,5 @@
+-- logo images were stored as absolute paths <working dir>/data/company/<id>.jpeg,
+-- keys are relative to filesystem storage root which defaults to <working dir>/data
+UPDATE logo
+SET image = regexp_replace(image, '^.*[/\\]data[/\\]company[/\\]', 'company/')
+WHERE image ~ '[/\\]data[/\\]company[/\\]';
@@ -0,0 +1,8 @@
+-- logos uploaded before content sniffing were always served as jpeg, their size is unknown
+ALTER TABLE logo
+    ADD COLUMN content_type varchar NOT NULL DEFAULT 'image/jpeg',
+    ADD COLUMN size         bigint  NOT NULL DEFAULT 0;
+
+ALTER TABLE logo
+    ALTER COLUMN content_type DROP DEFAULT,
+    ALTER COLUMN size DROP DEFAULT;
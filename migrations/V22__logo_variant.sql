@@ -0,0 +1,11 @@
+CREATE TABLE logo_variant
+(
+    logo_id      uuid    NOT NULL REFERENCES logo (id) ON DELETE CASCADE,
+    dimension    int     NOT NULL,
+    width        int     NOT NULL,
+    height       int     NOT NULL,
+    image        varchar NOT NULL,
+    content_type varchar NOT NULL,
+    size         bigint  NOT NULL,
+    PRIMARY KEY (logo_id, dimension)
+);
@@ -0,0 +1,16 @@
+-- every upload creates new logo version, current one is served, previous ones can be reverted to
+ALTER TABLE logo
+    ADD COLUMN version    int,
+    ADD COLUMN current    boolean     NOT NULL DEFAULT true,
+    ADD COLUMN created_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN created_by uuid;
+
+-- companies had at most one logo so far
+UPDATE logo SET version = 1;
+
+ALTER TABLE logo
+    ALTER COLUMN version SET NOT NULL,
+    ALTER COLUMN current DROP DEFAULT;
+
+CREATE UNIQUE INDEX logo_company_version_idx ON logo (company_id, version);
+CREATE UNIQUE INDEX logo_company_current_idx ON logo (company_id) WHERE current;
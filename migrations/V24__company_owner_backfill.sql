@@ -0,0 +1,15 @@
+-- companies created before memberships have no owners, so only administrators could manage them.
+-- Their creator, taken from company history or created_by, becomes the owner. Companies without a known
+-- creator stay ownerless until an administrator grants ownership via PUT /api/company/{id}/members/{userId}
+UPDATE company
+SET created_by = (SELECT user_id FROM company_history h
+                  WHERE h.company_id = company.id AND h.action = 'CREATE'
+                  ORDER BY changed_at LIMIT 1)
+WHERE created_by IS NULL;
+
+INSERT INTO company_member (company_id, user_id, role, granted_by)
+SELECT c.id, c.created_by, 'owner', c.created_by
+FROM company c
+WHERE c.created_by IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM company_member m WHERE m.company_id = c.id AND m.role = 'owner')
+ON CONFLICT (company_id, user_id) DO UPDATE SET role = 'owner';
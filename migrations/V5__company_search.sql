@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX company_name_trgm_idx ON company USING gin (name gin_trgm_ops);
+CREATE INDEX company_name_fts_idx ON company USING gin (to_tsvector('simple', name));
@@ -0,0 +1,11 @@
+ALTER TABLE company
+    ADD COLUMN description         varchar NOT NULL DEFAULT '',
+    ADD COLUMN website             varchar NOT NULL DEFAULT '',
+    ADD COLUMN industry            varchar NOT NULL DEFAULT '',
+    ADD COLUMN founded_year        integer NOT NULL DEFAULT 0,
+    ADD COLUMN headcount           integer NOT NULL DEFAULT 0,
+    ADD COLUMN address_street      varchar NOT NULL DEFAULT '',
+    ADD COLUMN address_city        varchar NOT NULL DEFAULT '',
+    ADD COLUMN address_region      varchar NOT NULL DEFAULT '',
+    ADD COLUMN address_postal_code varchar NOT NULL DEFAULT '',
+    ADD COLUMN address_country     char(2) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE company
+    ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX company_deleted_at_idx ON company (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX logo_company_id_idx ON logo (company_id);
@@ -0,0 +1,12 @@
+CREATE TABLE company_history
+(
+    id         uuid        NOT NULL PRIMARY KEY,
+    company_id uuid        NOT NULL,
+    user_id    uuid        NOT NULL,
+    action     varchar(16) NOT NULL,
+    changed_at timestamptz NOT NULL DEFAULT now(),
+    before     jsonb,
+    after      jsonb
+);
+
+CREATE INDEX company_history_company_id_idx ON company_history (company_id, changed_at DESC, id DESC);